@@ -3,38 +3,48 @@ package main
 import (
 	"context"
 	"encoding/gob"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
-	"time"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/FelipeBelloDultra/go-bid/internal/api"
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/config"
+	"github.com/FelipeBelloDultra/go-bid/internal/metrics"
 	"github.com/FelipeBelloDultra/go-bid/internal/services"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/FelipeBelloDultra/go-bid/internal/use-case/product"
 	"github.com/alexedwards/scs/pgxstore"
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/joho/godotenv"
 )
 
 func main() {
 	gob.Register(uuid.UUID{})
 
-	if err := godotenv.Load(); err != nil {
-		panic(err)
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
 	}
+	slog.Info("Configuration loaded", "config", cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	ctx := context.Background()
-	pool, err := pgxpool.New(ctx, fmt.Sprintf(
-		"user=%s password=%s host=%s port=%s dbname=%s",
-		os.Getenv("GOBID_DATABASE_USER"),
-		os.Getenv("GOBID_DATABASE_PASSWORD"),
-		os.Getenv("GOBID_DATABASE_HOST"),
-		os.Getenv("GOBID_DATABASE_PORT"),
-		os.Getenv("GOBID_DATABASE_NAME"),
-	))
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
 	if err != nil {
 		panic(err)
 	}
@@ -44,33 +54,118 @@ func main() {
 	if err := pool.Ping(ctx); err != nil {
 		panic(err)
 	}
+	metrics.RegisterPool(pool)
 
 	s := scs.New()
 	s.Store = pgxstore.New(pool)
-	s.Lifetime = 24 * time.Hour
+	s.Lifetime = cfg.Session.Lifetime
 	s.Cookie.HttpOnly = true
-	s.Cookie.SameSite = http.SameSiteLaxMode
+	s.Cookie.Secure = cfg.Session.CookieSecure
+	s.Cookie.SameSite, _ = cfg.Session.SameSiteMode()
+
+	store := pgstore.NewStore(pool)
+	clk := clock.Real()
 
 	api := api.API{
 		Router:         chi.NewMux(),
-		UserService:    services.NewUserService(pool),
-		ProductService: services.NewProductService(pool),
-		BidsService:    services.NewBidsService(pool),
-		Sessions:       s,
+		UserService:    services.NewUserService(store),
+		ProductService: services.NewProductService(store, clk),
+		BidsService: services.NewBidsService(store, clk, services.SoftClose{
+			Window:    cfg.Auction.SoftCloseWindow,
+			Extension: cfg.Auction.SoftCloseExtension,
+		}),
+		SettlementService: services.NewSettlementService(store, clk),
+		PubSub:            services.NewPubSubService(pool),
+		Sessions:          s,
+		Clock:             clk,
+		ProductRules: product.CreateProductRules{
+			Clock:              clk,
+			MinAuctionDuration: cfg.Auction.MinDuration,
+		},
+		CSRF: api.CSRFSettings{
+			Key:            []byte(cfg.CSRF.Key),
+			Secure:         cfg.CSRF.Secure,
+			TrustedOrigins: cfg.CSRF.TrustedOrigins,
+		},
 		WsUpgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // replace this with your own logic to check the origin of the request
-			},
+			CheckOrigin: api.AllowOrigins(cfg.WebSocket.AllowedOrigins),
+		},
+		ClientLimits: services.ClientLimits{
+			MaxMessageSize: cfg.WebSocket.MaxMessageSize,
+			ReadDeadline:   cfg.WebSocket.ReadDeadline,
+			WriteWait:      cfg.WebSocket.WriteWait,
+			SendQueueSize:  cfg.WebSocket.SendQueueSize,
+			OverflowPolicy: services.OverflowPolicy(cfg.WebSocket.OverflowPolicy),
 		},
 		AuctionLobby: services.AuctionLobby{
-			Rooms: make(map[uuid.UUID]*services.AuctionRoom),
+			Rooms:     make(map[uuid.UUID]*services.AuctionRoom),
+			Scheduled: make(map[uuid.UUID]clock.Timer),
 		},
 	}
 
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		api.PubSub.RunLeaderElection(ctx)
+	}()
+	go func() {
+		defer background.Done()
+		api.PubSub.Listen(ctx, api.HandleAuctionEvent)
+	}()
+
+	if err := api.RestoreAuctionRooms(ctx); err != nil {
+		panic(err)
+	}
+
 	api.BindRoutes()
 
-	fmt.Println("listening on port :3333...")
-	if err := http.ListenAndServe("localhost:3333", api.Router); err != nil {
+	srv := &http.Server{
+		Addr:    cfg.ListenAddr,
+		Handler: api.Router,
+	}
+
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("Listening", "addr", cfg.ListenAddr)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// Metrics are served apart from the API so they stay off the public
+	// network.
+	var metricsSrv *http.Server
+	if cfg.MetricsAddr != "" {
+		metricsSrv = &http.Server{
+			Addr:    cfg.MetricsAddr,
+			Handler: api.MetricsHandler(),
+		}
+		go func() {
+			slog.Info("Serving metrics", "addr", cfg.MetricsAddr)
+			serverErr <- metricsSrv.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
 		panic(err)
+	case <-ctx.Done():
 	}
+
+	slog.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to shut down the HTTP server", "error", err)
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to shut down the metrics server", "error", err)
+		}
+	}
+	if err := api.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to drain WebSocket clients", "error", err)
+	}
+
+	background.Wait()
 }
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/services"
+	"github.com/gorilla/websocket"
+)
+
+// placeBidMessage is the PlaceBid request a browser sends.
+type placeBidMessage struct {
+	Kind     services.MessageKind `json:"kind"`
+	Amount   int64                `json:"amount"`
+	Currency string               `json:"currency"`
+}
+
+type bidAck struct {
+	accepted bool
+	reason   string
+	minimum  int64
+}
+
+// bidder places randomized bids on one connection, one at a time, at an
+// average of rate bids per second.
+type bidder struct {
+	conn       *websocket.Conn
+	stats      *stats
+	currency   string
+	rate       float64
+	maxRaise   int64
+	ackTimeout time.Duration
+	writeWait  time.Duration
+
+	// price is the latest price seen in the room.
+	price atomic.Int64
+	acks  chan bidAck
+	done  chan struct{}
+}
+
+func newBidder(conn *websocket.Conn, stats *stats, cfg config, rate float64) *bidder {
+	return &bidder{
+		conn:       conn,
+		stats:      stats,
+		currency:   cfg.Currency,
+		rate:       rate,
+		maxRaise:   cfg.MaxRaise,
+		ackTimeout: cfg.AckTimeout,
+		writeWait:  cfg.WriteWait,
+		acks:       make(chan bidAck, 16),
+		done:       make(chan struct{}),
+	}
+}
+
+// run bids until ctx is done or the connection closes, then closes it.
+func (b *bidder) run(ctx context.Context) {
+	go b.read()
+	defer func() {
+		b.conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(b.writeWait),
+		)
+		b.conn.Close()
+		<-b.done
+	}()
+
+	// Acks of bids that timed out are still on their way; they must not be
+	// taken for the answer to a later bid.
+	late := 0
+	// increment is the raise the room last asked for over the latest price.
+	increment := int64(1)
+	for {
+		wait := time.Duration(rand.ExpFloat64() / b.rate * float64(time.Second))
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-time.After(wait):
+		}
+
+		amount := b.price.Load() + increment + rand.Int64N(b.maxRaise)
+		b.conn.SetWriteDeadline(time.Now().Add(b.writeWait))
+		sentAt := time.Now()
+		if err := b.conn.WriteJSON(placeBidMessage{Kind: services.PlaceBid, Amount: amount, Currency: b.currency}); err != nil {
+			b.stats.add(&b.stats.sendErrors)
+			return
+		}
+		b.stats.add(&b.stats.sent)
+
+		timeout := time.After(b.ackTimeout)
+	waitForAck:
+		for {
+			select {
+			case ack := <-b.acks:
+				if late > 0 {
+					late--
+					continue
+				}
+
+				b.stats.ack(ack.accepted, ack.reason, time.Since(sentAt))
+				if ack.accepted {
+					b.raisePrice(amount)
+				} else if ack.minimum > 0 {
+					increment = max(ack.minimum-b.price.Load(), 1)
+				}
+				break waitForAck
+			case <-timeout:
+				b.stats.add(&b.stats.ackTimeouts)
+				late++
+				break waitForAck
+			case <-ctx.Done():
+				return
+			case <-b.done:
+				return
+			}
+		}
+	}
+}
+
+// read follows the room's price and hands the answers to bids to run.
+func (b *bidder) read() {
+	defer close(b.done)
+
+	for {
+		var m services.Message
+		if err := b.conn.ReadJSON(&m); err != nil {
+			// Closing the connection from run, or the room closing it
+			// when the auction ends, is not a drop.
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) && !errors.Is(err, net.ErrClosed) {
+				b.stats.add(&b.stats.dropped)
+			}
+			return
+		}
+
+		switch m.Kind {
+		case services.AuctionState, services.NewBidPlaced:
+			b.raisePrice(m.Amount)
+		case services.SuccessfullyPlacedBid:
+			b.deliver(bidAck{accepted: true})
+		case services.FailedToPlaceBid:
+			b.deliver(bidAck{reason: m.Message, minimum: m.Amount})
+		case services.AuctionFinished, services.ServerGoingAway:
+			b.stats.add(&b.stats.finished)
+		}
+	}
+}
+
+// deliver hands ack to run without blocking the reader once run has stopped
+// waiting for acks.
+func (b *bidder) deliver(ack bidAck) {
+	select {
+	case b.acks <- ack:
+	default:
+	}
+}
+
+func (b *bidder) raisePrice(price int64) {
+	for {
+		current := b.price.Load()
+		if price <= current || b.price.CompareAndSwap(current, price) {
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// apiClient is one user's session with the public API. Its cookie jar holds
+// the session and CSRF cookies.
+type apiClient struct {
+	baseURL   string
+	jar       http.CookieJar
+	http      *http.Client
+	csrfToken string
+}
+
+func newAPIClient(target string, timeout time.Duration) (*apiClient, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiClient{
+		baseURL: strings.TrimSuffix(target, "/") + "/api/v1",
+		jar:     jar,
+		http:    &http.Client{Jar: jar, Timeout: timeout},
+	}, nil
+}
+
+// do sends body as JSON and decodes the response into out, unless the
+// response does not have the wanted status.
+func (c *apiClient) do(ctx context.Context, method, path string, body any, wantStatus int, out any) error {
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, &reader)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if c.csrfToken != "" {
+		r.Header.Set("X-CSRF-Token", c.csrfToken)
+	}
+
+	res, err := c.http.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != wantStatus {
+		return fmt.Errorf("%s %s: status %d: %s", method, path, res.StatusCode, bytes.TrimSpace(raw))
+	}
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(raw, out)
+}
+
+// signUp creates the user and signs it in.
+func (c *apiClient) signUp(ctx context.Context, userName, email, password string) error {
+	err := c.do(ctx, http.MethodPost, "/users/sign-up", map[string]any{
+		"user_name": userName,
+		"email":     email,
+		"password":  password,
+		"bio":       "a simulated bidder created by loadgen",
+	}, http.StatusCreated, nil)
+	if err != nil {
+		return err
+	}
+
+	var token struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/csrf-token", nil, http.StatusOK, &token); err != nil {
+		return err
+	}
+	c.csrfToken = token.CSRFToken
+
+	return c.do(ctx, http.MethodPost, "/users/sign-in", map[string]any{
+		"email":    email,
+		"password": password,
+	}, http.StatusOK, nil)
+}
+
+// createProduct lists an english auction ending at auctionEnd.
+func (c *apiClient) createProduct(ctx context.Context, name string, basePrice money.Money, auctionEnd time.Time) (uuid.UUID, error) {
+	var created struct {
+		ProductID uuid.UUID `json:"product_id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/products/", map[string]any{
+		"product_name": name,
+		"description":  "an auction created by loadgen",
+		"base_price":   basePrice,
+		"auction_end":  auctionEnd,
+	}, http.StatusCreated, &created)
+
+	return created.ProductID, err
+}
+
+// subscribe opens a WebSocket connection to the product's auction room.
+func (c *apiClient) subscribe(ctx context.Context, productId uuid.UUID, timeout time.Duration) (*websocket.Conn, error) {
+	dialer := websocket.Dialer{Jar: c.jar, HandshakeTimeout: timeout}
+	url := "ws" + strings.TrimPrefix(c.baseURL, "http") + "/products/ws/subscribe/" + productId.String()
+
+	conn, res, err := dialer.DialContext(ctx, url, nil)
+	if err != nil && res != nil {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("%w: status %d: %s", err, res.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return conn, err
+}
@@ -0,0 +1,278 @@
+// Command loadgen simulates a crowd of bidders against a running API: it
+// signs up users, lists auctions, opens WebSocket connections to their rooms
+// and places randomized bids, then reports throughput, bid-ack latency and
+// errors.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+type config struct {
+	Target           string
+	Users            int
+	Auctions         int
+	Connections      int
+	BidRate          float64
+	Duration         time.Duration
+	Currency         string
+	BasePrice        int64
+	MaxRaise         int64
+	AckTimeout       time.Duration
+	WriteWait        time.Duration
+	RequestTimeout   time.Duration
+	AuctionDuration  time.Duration
+	SetupConcurrency int
+	ReportEvery      time.Duration
+}
+
+func loadConfig(args []string) (config, error) {
+	var cfg config
+
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	fs.StringVar(&cfg.Target, "target", "http://localhost:3333", "base URL of the API")
+	fs.IntVar(&cfg.Users, "users", 50, "users to sign up")
+	fs.IntVar(&cfg.Auctions, "auctions", 5, "auctions to list, spread over the users")
+	fs.IntVar(&cfg.Connections, "connections", 250, "WebSocket connections to open, spread over the auctions")
+	fs.Float64Var(&cfg.BidRate, "bid-rate", 100, "bids per second across all connections")
+	fs.DurationVar(&cfg.Duration, "duration", time.Minute, "how long to bid for")
+	fs.StringVar(&cfg.Currency, "currency", "USD", "currency of the auctions")
+	fs.Int64Var(&cfg.BasePrice, "base-price", 1000, "base price of the auctions, in cents")
+	fs.Int64Var(&cfg.MaxRaise, "max-raise", 500, "largest amount, in cents, a bid raises the latest price by")
+	fs.DurationVar(&cfg.AckTimeout, "ack-timeout", 10*time.Second, "time to wait for a bid to be answered")
+	fs.DurationVar(&cfg.WriteWait, "write-wait", 10*time.Second, "time allowed to write a WebSocket message")
+	fs.DurationVar(&cfg.RequestTimeout, "request-timeout", 30*time.Second, "timeout of HTTP requests and WebSocket handshakes")
+	fs.DurationVar(&cfg.AuctionDuration, "auction-duration", 3*time.Hour, "time from now to the end of the auctions; must satisfy the server's minimum")
+	fs.IntVar(&cfg.SetupConcurrency, "setup-concurrency", 16, "sign-ups, listings and dials in flight at once")
+	fs.DurationVar(&cfg.ReportEvery, "report-every", 5*time.Second, "interval between progress logs")
+
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return config{}, err
+	}
+
+	return cfg, nil
+}
+
+// Validate reports every invalid setting at once.
+func (c config) Validate() error {
+	var errs []error
+	check := func(ok bool, msg string) {
+		if !ok {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	check(c.Users >= 1, "users must be at least 1")
+	check(c.Auctions >= 1 && c.Auctions <= c.Users, "auctions must be between 1 and users")
+	// A room keeps one connection per user, so every connection needs its
+	// own pair of user and auction.
+	check(c.Connections >= 1 && c.Connections <= c.Users*c.Auctions, "connections must be between 1 and users * auctions")
+	check(c.BidRate > 0, "bid-rate must be positive")
+	check(c.Duration > 0, "duration must be positive")
+	check(c.BasePrice > 0, "base-price must be positive")
+	check(c.MaxRaise >= 1, "max-raise must be at least 1")
+	check(c.AckTimeout > 0, "ack-timeout must be positive")
+	check(c.WriteWait > 0, "write-wait must be positive")
+	check(c.RequestTimeout > 0, "request-timeout must be positive")
+	check(c.AuctionDuration > 0, "auction-duration must be positive")
+	check(c.SetupConcurrency >= 1, "setup-concurrency must be at least 1")
+	check(c.ReportEvery > 0, "report-every must be positive")
+
+	return errors.Join(errs...)
+}
+
+func main() {
+	cfg, err := loadConfig(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg); err != nil {
+		slog.Error("Load test failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg config) error {
+	runId := "loadgen-" + uuid.NewString()[:8]
+	slog.Info("Setting up", "run", runId, "users", cfg.Users, "auctions", cfg.Auctions, "target", cfg.Target)
+
+	users, err := signUpUsers(ctx, cfg, runId)
+	if err != nil {
+		return fmt.Errorf("failed to sign up users: %w", err)
+	}
+
+	auctions, err := createAuctions(ctx, cfg, runId, users)
+	if err != nil {
+		return fmt.Errorf("failed to create auctions: %w", err)
+	}
+
+	stats := newStats()
+	conns := openConnections(ctx, cfg, users, auctions, stats)
+	if len(conns) == 0 {
+		return errors.New("no connection could be opened")
+	}
+	slog.Info("Bidding", "connections", len(conns), "bid_rate", cfg.BidRate, "duration", cfg.Duration)
+
+	bidCtx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	start := time.Now()
+	rate := cfg.BidRate / float64(len(conns))
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newBidder(conn, stats, cfg, rate).run(bidCtx)
+		}()
+	}
+
+	go logProgress(bidCtx, cfg.ReportEvery, stats)
+
+	wg.Wait()
+	stats.report(os.Stdout, time.Since(start))
+
+	return nil
+}
+
+// forEach calls fn for 0..n-1 with at most concurrency calls in flight and
+// returns the first error.
+func forEach(n, concurrency int, fn func(i int) error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for i := range n {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func signUpUsers(ctx context.Context, cfg config, runId string) ([]*apiClient, error) {
+	users := make([]*apiClient, cfg.Users)
+	err := forEach(cfg.Users, cfg.SetupConcurrency, func(i int) error {
+		client, err := newAPIClient(cfg.Target, cfg.RequestTimeout)
+		if err != nil {
+			return err
+		}
+
+		userName := fmt.Sprintf("%s-%d", runId, i)
+		if err := client.signUp(ctx, userName, userName+"@gobid.test", "loadgen-password"); err != nil {
+			return err
+		}
+		users[i] = client
+
+		return nil
+	})
+
+	return users, err
+}
+
+// createAuctions lists one english auction per seller, the first users
+// taking turns.
+func createAuctions(ctx context.Context, cfg config, runId string, users []*apiClient) ([]uuid.UUID, error) {
+	auctions := make([]uuid.UUID, cfg.Auctions)
+	auctionEnd := time.Now().Add(cfg.AuctionDuration)
+	basePrice := money.Money{Amount: cfg.BasePrice, Currency: cfg.Currency}
+
+	err := forEach(cfg.Auctions, cfg.SetupConcurrency, func(i int) error {
+		productId, err := users[i%len(users)].createProduct(ctx, fmt.Sprintf("%s auction %d", runId, i), basePrice, auctionEnd)
+		if err != nil {
+			return err
+		}
+		auctions[i] = productId
+
+		return nil
+	})
+
+	return auctions, err
+}
+
+// openConnections subscribes connection j to auction j % M as user j / M,
+// so that no user joins the same room twice. Connections that fail to open
+// are counted and left out.
+func openConnections(ctx context.Context, cfg config, users []*apiClient, auctions []uuid.UUID, stats *stats) []*websocket.Conn {
+	conns := make([]*websocket.Conn, cfg.Connections)
+	forEach(cfg.Connections, cfg.SetupConcurrency, func(j int) error {
+		user := users[j/len(auctions)]
+		conn, err := user.subscribe(ctx, auctions[j%len(auctions)], cfg.RequestTimeout)
+		if err != nil {
+			slog.Warn("Failed to open connection", "connection", j, "error", err)
+			stats.add(&stats.connectErrors)
+			return nil
+		}
+		conns[j] = conn
+		stats.add(&stats.connections)
+
+		return nil
+	})
+
+	opened := conns[:0]
+	for _, conn := range conns {
+		if conn != nil {
+			opened = append(opened, conn)
+		}
+	}
+
+	return opened
+}
+
+func logProgress(ctx context.Context, every time.Duration, stats *stats) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	stats.progress(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sent, rate, acked := stats.progress(now)
+			slog.Info("Progress", "sent", sent, "acked", acked, "bids_per_second", fmt.Sprintf("%.1f", rate))
+		}
+	}
+}
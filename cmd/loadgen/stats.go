@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// stats collects the outcome of every bid and the errors of the run. It is
+// safe for concurrent use.
+type stats struct {
+	mu sync.Mutex
+
+	connections    int
+	connectErrors  int
+	dropped        int
+	sent           int
+	accepted       int
+	rejected       map[string]int
+	ackTimeouts    int
+	sendErrors     int
+	finished       int
+	ackLatencies   []time.Duration
+	lastSent       int
+	lastReportedAt time.Time
+}
+
+func newStats() *stats {
+	return &stats{rejected: make(map[string]int)}
+}
+
+func (s *stats) add(counter *int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	*counter++
+}
+
+// ack records the answer to a bid, accepted or rejected with reason.
+func (s *stats) ack(accepted bool, reason string, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if accepted {
+		s.accepted++
+	} else {
+		s.rejected[reason]++
+	}
+	s.ackLatencies = append(s.ackLatencies, latency)
+}
+
+// progress summarizes the bids sent since the previous call.
+func (s *stats) progress(now time.Time) (sent int, rate float64, acked int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lastReportedAt.IsZero() {
+		rate = float64(s.sent-s.lastSent) / now.Sub(s.lastReportedAt).Seconds()
+	}
+	s.lastSent, s.lastReportedAt = s.sent, now
+
+	return s.sent, rate, len(s.ackLatencies)
+}
+
+func (s *stats) report(w io.Writer, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latencies := slices.Clone(s.ackLatencies)
+	slices.Sort(latencies)
+
+	rejected := 0
+	for _, count := range s.rejected {
+		rejected += count
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "duration\t%s\n", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(tw, "connections\t%d opened, %d failed, %d dropped\n", s.connections, s.connectErrors, s.dropped)
+	fmt.Fprintf(tw, "bids sent\t%d (%.1f/s)\n", s.sent, float64(s.sent)/elapsed.Seconds())
+	fmt.Fprintf(tw, "bids acked\t%d (%.1f/s)\n", len(latencies), float64(len(latencies))/elapsed.Seconds())
+	fmt.Fprintf(tw, "  accepted\t%d\n", s.accepted)
+	fmt.Fprintf(tw, "  rejected\t%d\n", rejected)
+	for _, reason := range slices.Sorted(maps.Keys(s.rejected)) {
+		fmt.Fprintf(tw, "    %s\t%d\n", reason, s.rejected[reason])
+	}
+	fmt.Fprintf(tw, "errors\t%d ack timeouts, %d send errors\n", s.ackTimeouts, s.sendErrors)
+	if s.finished > 0 {
+		fmt.Fprintf(tw, "auctions finished\t%d connections saw their auction end\n", s.finished)
+	}
+	if len(latencies) > 0 {
+		fmt.Fprintf(tw, "ack latency\tp50 %s, p90 %s, p99 %s, max %s\n",
+			percentile(latencies, 50),
+			percentile(latencies, 90),
+			percentile(latencies, 99),
+			latencies[len(latencies)-1],
+		)
+	}
+	tw.Flush()
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	rank = min(max(rank, 0), len(sorted)-1)
+
+	return sorted[rank].Round(time.Microsecond)
+}
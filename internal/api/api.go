@@ -1,18 +1,32 @@
 package api
 
 import (
+	"sync"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
 	"github.com/FelipeBelloDultra/go-bid/internal/services"
+	"github.com/FelipeBelloDultra/go-bid/internal/use-case/product"
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 )
 
 type API struct {
-	Router         *chi.Mux
-	Sessions       *scs.SessionManager
-	UserService    services.UserService
-	ProductService services.ProductService
-	BidsService    services.BidsService
-	WsUpgrader     websocket.Upgrader
-	AuctionLobby   services.AuctionLobby
+	Router            *chi.Mux
+	Sessions          *scs.SessionManager
+	Clock             clock.Clock
+	UserService       services.UserService
+	ProductService    services.ProductService
+	BidsService       services.BidsService
+	SettlementService services.SettlementService
+	PubSub            *services.PubSubService
+	ProductRules      product.CreateProductRules
+	CSRF              CSRFSettings
+	WsUpgrader        websocket.Upgrader
+	ClientLimits      services.ClientLimits
+	AuctionLobby      services.AuctionLobby
+
+	// clients tracks the read and write loops of every WebSocket client so
+	// Shutdown can wait for them.
+	clients sync.WaitGroup
 }
@@ -3,9 +3,11 @@ package api
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	jsonutils "github.com/FelipeBelloDultra/go-bid/internal/json-utils"
 	"github.com/FelipeBelloDultra/go-bid/internal/services"
+	"github.com/FelipeBelloDultra/go-bid/internal/use-case/product"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
@@ -20,7 +22,18 @@ func (api *API) handleSubscribeUserToAuction(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	_, err = api.ProductService.GetProductByID(r.Context(), productId)
+	var lastSequence uint64
+	if raw := r.URL.Query().Get("last_sequence"); raw != "" {
+		lastSequence, err = strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			jsonutils.EncodeJSON(w, r, http.StatusBadRequest, map[string]any{
+				"error": "invalid last sequence",
+			})
+			return
+		}
+	}
+
+	product, err := api.ProductService.GetProductByID(r.Context(), productId)
 	if err != nil {
 		if errors.Is(err, services.ErrProductNotFound) {
 			jsonutils.EncodeJSON(w, r, http.StatusNotFound, map[string]any{
@@ -43,11 +56,31 @@ func (api *API) handleSubscribeUserToAuction(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// The client's loops are counted under the lobby lock, so Shutdown, which
+	// closes the lobby under the same lock, waits for every client it lets in.
 	api.AuctionLobby.Lock()
+	if api.AuctionLobby.Closed {
+		api.AuctionLobby.Unlock()
+		jsonutils.EncodeJSON(w, r, http.StatusServiceUnavailable, map[string]any{
+			"error": "server is shutting down",
+		})
+		return
+	}
 	room, ok := api.AuctionLobby.Rooms[productId]
+	if ok {
+		api.clients.Add(2)
+	}
 	api.AuctionLobby.Unlock()
 
 	if !ok {
+		if api.Clock.Now().Before(product.AuctionStart) {
+			jsonutils.EncodeJSON(w, r, http.StatusBadRequest, map[string]any{
+				"error":         "auction has not started yet",
+				"auction_start": product.AuctionStart,
+			})
+			return
+		}
+
 		jsonutils.EncodeJSON(w, r, http.StatusBadRequest, map[string]any{
 			"error": "auction has ended",
 		})
@@ -56,17 +89,114 @@ func (api *API) handleSubscribeUserToAuction(w http.ResponseWriter, r *http.Requ
 
 	conn, err := api.WsUpgrader.Upgrade(w, r, nil)
 	if err != nil {
+		api.clients.Add(-2)
+		jsonutils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	client := services.NewClient(room, conn, userId, api.ClientLimits)
+	if r.URL.Query().Get("stream") == room.Stream {
+		client.LastSequence = lastSequence
+	}
+
+	select {
+	case room.Register <- client:
+	case <-room.Done():
+		api.clients.Add(-2)
+		conn.Close()
+		return
+	}
+
+	go func() {
+		defer api.clients.Done()
+		client.ReadEventLoop()
+	}()
+	go func() {
+		defer api.clients.Done()
+		client.WriteEventLoop()
+	}()
+}
+
+func (api *API) handleGetAuctionResult(w http.ResponseWriter, r *http.Request) {
+	rawProductId := chi.URLParam(r, "product_id")
+	productId, err := uuid.Parse(rawProductId)
+	if err != nil {
+		jsonutils.EncodeJSON(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	result, err := api.SettlementService.GetResultByProductID(r.Context(), productId)
+	if err != nil {
+		if errors.Is(err, services.ErrAuctionNotSettled) {
+			jsonutils.EncodeJSON(w, r, http.StatusNotFound, map[string]any{
+				"error": "auction has not been settled yet",
+			})
+			return
+		}
+
 		jsonutils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]any{
 			"error": "internal server error",
 		})
 		return
 	}
 
-	client := services.NewClient(room, conn, userId)
+	jsonutils.EncodeJSON(w, r, http.StatusOK, result)
+}
+
+func (api *API) handleListBids(w http.ResponseWriter, r *http.Request) {
+	productId, err := uuid.Parse(chi.URLParam(r, "product_id"))
+	if err != nil {
+		jsonutils.EncodeJSON(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
 
-	room.Register <- client
-	go client.ReadEventLoop()
-	go client.WriteEventLoop()
-	for {
+	data := product.NewListBidsReq(r.URL.Query())
+	if problems := data.Valid(r.Context()); len(problems) > 0 {
+		jsonutils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
 	}
+
+	userId, ok := api.Sessions.Get(r.Context(), AuthenticationSessionKey).(uuid.UUID)
+	if !ok {
+		jsonutils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	page, err := api.BidsService.ListBidHistory(r.Context(), productId, services.BidHistoryQuery{
+		Viewer:        userId,
+		ShowUserNames: data.ShowUserNames,
+		Cursor:        data.Cursor,
+		Limit:         data.Limit,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrProductNotFound):
+			jsonutils.EncodeJSON(w, r, http.StatusNotFound, map[string]any{
+				"error": "product not found",
+			})
+		case errors.Is(err, services.ErrNotProductSeller):
+			jsonutils.EncodeJSON(w, r, http.StatusForbidden, map[string]any{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrInvalidCursor):
+			jsonutils.EncodeJSON(w, r, http.StatusUnprocessableEntity, map[string]any{
+				"cursor": "this field must be a cursor returned by this endpoint",
+			})
+		default:
+			jsonutils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+		}
+		return
+	}
+
+	jsonutils.EncodeJSON(w, r, http.StatusOK, page)
 }
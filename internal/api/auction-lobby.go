@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/services"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+)
+
+const auctionEventTimeout = 5 * time.Second
+
+// openAuctionRoom starts the product's auction room, or schedules it to start
+// at the product's auction start, and reports whether it was scheduled.
+// Products that already have a running or scheduled room are left untouched,
+// and so is every product once the lobby has been closed.
+func (api *API) openAuctionRoom(product pgstore.Product) bool {
+	api.AuctionLobby.Lock()
+	defer api.AuctionLobby.Unlock()
+
+	if api.AuctionLobby.Closed {
+		return false
+	}
+
+	if _, ok := api.AuctionLobby.Rooms[product.ID]; ok {
+		return false
+	}
+	if _, ok := api.AuctionLobby.Scheduled[product.ID]; ok {
+		return true
+	}
+
+	if wait := product.AuctionStart.Sub(api.Clock.Now()); wait > 0 {
+		slog.Info("Auction has been scheduled", "auctionID", product.ID, "auctionStart", product.AuctionStart)
+
+		api.AuctionLobby.Scheduled[product.ID] = api.Clock.AfterFunc(wait, func() {
+			api.AuctionLobby.Lock()
+			delete(api.AuctionLobby.Scheduled, product.ID)
+			api.AuctionLobby.Unlock()
+
+			api.openAuctionRoom(product)
+		})
+		return true
+	}
+
+	api.startAuctionRoom(product)
+	return false
+}
+
+// startAuctionRoom registers and runs a room for product. The caller must hold
+// the lobby lock.
+func (api *API) startAuctionRoom(product pgstore.Product) {
+	productId := product.ID
+	auctionRoom := services.NewAuctionRoom(
+		context.Background(),
+		product,
+		api.BidsService,
+		api.SettlementService,
+		api.PubSub,
+		api.Clock,
+	)
+
+	api.AuctionLobby.Rooms[productId] = auctionRoom
+
+	go func() {
+		auctionRoom.Run()
+
+		api.AuctionLobby.Lock()
+		delete(api.AuctionLobby.Rooms, productId)
+		api.AuctionLobby.Unlock()
+	}()
+}
+
+// Shutdown closes the lobby and stops every room, which tells their clients
+// that the server is going away, then waits until ctx is done for the clients'
+// connections to close.
+func (api *API) Shutdown(ctx context.Context) error {
+	api.AuctionLobby.Lock()
+	api.AuctionLobby.Closed = true
+	for productId, timer := range api.AuctionLobby.Scheduled {
+		timer.Stop()
+		delete(api.AuctionLobby.Scheduled, productId)
+	}
+	rooms := make([]*services.AuctionRoom, 0, len(api.AuctionLobby.Rooms))
+	for _, room := range api.AuctionLobby.Rooms {
+		rooms = append(rooms, room)
+	}
+	api.AuctionLobby.Unlock()
+
+	for _, room := range rooms {
+		room.Stop()
+	}
+
+	clientsDone := make(chan struct{})
+	go func() {
+		api.clients.Wait()
+		close(clientsDone)
+	}()
+
+	select {
+	case <-clientsDone:
+		slog.Info("Auction rooms have been shut down", "rooms", len(rooms))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HandleAuctionEvent applies an event published by another instance to this
+// instance's replica of the room.
+func (api *API) HandleAuctionEvent(event services.AuctionEvent) {
+	if event.Kind == services.AuctionEventOpened {
+		ctx, cancel := context.WithTimeout(context.Background(), auctionEventTimeout)
+		defer cancel()
+
+		product, err := api.ProductService.GetProductByID(ctx, event.ProductID)
+		if err != nil {
+			slog.Error("Failed to open replicated auction room", "auctionID", event.ProductID, "error", err)
+			return
+		}
+
+		api.openAuctionRoom(product)
+		return
+	}
+
+	api.AuctionLobby.Lock()
+	room, ok := api.AuctionLobby.Rooms[event.ProductID]
+	api.AuctionLobby.Unlock()
+
+	if ok {
+		room.Deliver(event)
+	}
+}
+
+// RestoreAuctionRooms registers a room for every unsold product whose auction
+// is still running and schedules the ones that have not started yet, so
+// auctions survive a server restart. Auctions that ended while the server was
+// down are settled instead.
+func (api *API) RestoreAuctionRooms(ctx context.Context) error {
+	ended, err := api.ProductService.ListUnsettledEndedProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range ended {
+		if _, err := api.SettlementService.Settle(ctx, product.ID); err != nil {
+			return err
+		}
+	}
+
+	// Scheduled products are listed first so that an auction starting between
+	// both queries is still found by the second one.
+	scheduled, err := api.ProductService.ListScheduledProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range scheduled {
+		api.openAuctionRoom(product)
+	}
+
+	products, err := api.ProductService.ListActiveProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		api.openAuctionRoom(product)
+	}
+
+	slog.Info(
+		"Auction rooms restored",
+		"count", len(products),
+		"scheduled", len(scheduled),
+		"settled", len(ended),
+	)
+	return nil
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
+	"github.com/FelipeBelloDultra/go-bid/internal/services"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+)
+
+func TestRestoreAuctionRooms(t *testing.T) {
+	server := newTestServer(t)
+	seller := server.signUp("restorer")
+	ctx := context.Background()
+	start := server.Clock.Now()
+
+	createProduct := func(auctionStart, auctionEnd time.Time) uuid.UUID {
+		t.Helper()
+
+		id, err := server.Store.CreateProduct(ctx, pgstore.CreateProductParams{
+			SellerID:      seller.ID,
+			ProductName:   "restored auction",
+			Description:   "a product listed before the server restarted",
+			BasePrice:     1000,
+			Currency:      "USD",
+			AuctionType:   pgstore.AuctionTypeEnglish,
+			AuctionStart:  auctionStart,
+			AuctionEnd:    auctionEnd,
+			BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		})
+		if err != nil {
+			t.Fatalf("failed to create product: %v", err)
+		}
+		return id
+	}
+	open := createProduct(start.Add(-time.Hour), start.Add(time.Hour))
+	scheduled := createProduct(start.Add(30*time.Minute), start.Add(2*time.Hour))
+	ended := createProduct(start.Add(-2*time.Hour), start.Add(-time.Hour))
+
+	if err := server.API.RestoreAuctionRooms(ctx); err != nil {
+		t.Fatalf("failed to restore auction rooms: %v", err)
+	}
+
+	lobby := &server.API.AuctionLobby
+	room := func(id uuid.UUID) *services.AuctionRoom {
+		lobby.Lock()
+		defer lobby.Unlock()
+
+		return lobby.Rooms[id]
+	}
+	isScheduled := func(id uuid.UUID) bool {
+		lobby.Lock()
+		defer lobby.Unlock()
+
+		_, ok := lobby.Scheduled[id]
+		return ok
+	}
+	// join registers a client with the room, which has armed its deadline
+	// once it takes the registration.
+	join := func(room *services.AuctionRoom) {
+		room.Register <- services.NewClient(room, nil, seller.ID, services.ClientLimits{})
+	}
+	expectDone := func(room *services.AuctionRoom, done bool) {
+		t.Helper()
+
+		if !done {
+			select {
+			case <-room.Done():
+				t.Fatalf("expected the auction of %s to still be running", room.ID)
+			default:
+			}
+			return
+		}
+
+		select {
+		case <-room.Done():
+		case <-time.After(messageTimeout):
+			t.Fatalf("expected the auction of %s to have ended", room.ID)
+		}
+	}
+
+	openRoom := room(open)
+	if openRoom == nil || !openRoom.AuctionEnd.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected a room for the open auction ending at its end, got %+v", openRoom)
+	}
+	if room(scheduled) != nil || !isScheduled(scheduled) {
+		t.Fatal("expected the scheduled auction to be scheduled without a room")
+	}
+	if room(ended) != nil || isScheduled(ended) {
+		t.Fatal("expected the ended auction to have no room")
+	}
+	if _, err := server.Store.GetAuctionResultByProductId(ctx, ended); err != nil {
+		t.Fatalf("expected the ended auction to be settled: %v", err)
+	}
+	join(openRoom)
+
+	server.Clock.Set(start.Add(30 * time.Minute))
+	scheduledRoom := room(scheduled)
+	if scheduledRoom == nil || isScheduled(scheduled) || !scheduledRoom.AuctionEnd.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("expected the scheduled auction to open at its start, got %+v", scheduledRoom)
+	}
+	join(scheduledRoom)
+
+	server.Clock.Set(start.Add(time.Hour - time.Microsecond))
+	expectDone(openRoom, false)
+
+	server.Clock.Set(start.Add(time.Hour))
+	expectDone(openRoom, true)
+	expectDone(scheduledRoom, false)
+
+	server.Clock.Set(start.Add(2 * time.Hour))
+	expectDone(scheduledRoom, true)
+}
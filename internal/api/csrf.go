@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+
+	jsonutils "github.com/FelipeBelloDultra/go-bid/internal/json-utils"
+	"github.com/gorilla/csrf"
+)
+
+// CSRFSettings configures the CSRF protection of cookie-authenticated routes.
+type CSRFSettings struct {
+	// Key authenticates the CSRF cookie and must be 32 bytes long.
+	Key []byte
+	// Secure restricts the CSRF cookie to HTTPS.
+	Secure bool
+	// TrustedOrigins are the origins, besides the API's own, allowed to send
+	// unsafe requests, e.g. the SPA's origin.
+	TrustedOrigins []string
+}
+
+// csrfProtect returns a middleware that rejects unsafe requests coming from an
+// untrusted origin or lacking the token served by /csrf-token in the
+// X-CSRF-Token header. Routes that do not rely on the session cookie are left
+// out of it.
+func (api *API) csrfProtect() func(http.Handler) http.Handler {
+	trustedHosts := make([]string, 0, len(api.CSRF.TrustedOrigins))
+	for _, origin := range api.CSRF.TrustedOrigins {
+		if u, err := url.Parse(origin); err == nil {
+			trustedHosts = append(trustedHosts, u.Host)
+		}
+	}
+
+	protect := csrf.Protect(
+		api.CSRF.Key,
+		csrf.Secure(api.CSRF.Secure),
+		csrf.Path("/"),
+		csrf.SameSite(csrf.SameSiteLaxMode),
+		csrf.TrustedOrigins(trustedHosts),
+		csrf.ErrorHandler(http.HandlerFunc(handleCSRFFailure)),
+	)
+	isAllowed := AllowOrigins(api.CSRF.TrustedOrigins)
+
+	return func(next http.Handler) http.Handler {
+		protected := protect(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// gorilla/csrf only checks the Referer of requests it knows to be
+			// HTTPS, which a server behind a proxy never does, so the Origin
+			// browsers send is checked here.
+			if !isSafeMethod(r.Method) && !isAllowed(r) {
+				jsonutils.EncodeJSON(w, r, http.StatusForbidden, map[string]any{
+					"error": "origin not allowed",
+				})
+				return
+			}
+
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
+
+func handleCSRFFailure(w http.ResponseWriter, r *http.Request) {
+	jsonutils.EncodeJSON(w, r, http.StatusForbidden, map[string]any{
+		"error": "invalid csrf token",
+		"cause": csrf.FailureReason(r).Error(),
+	})
+}
+
+func isSafeMethod(method string) bool {
+	return slices.Contains([]string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodOptions,
+		http.MethodTrace,
+	}, method)
+}
+
+// AllowOrigins returns an origin check accepting requests without an Origin
+// header (non-browser clients), requests from the API's own host and requests
+// from one of origins, e.g. for websocket.Upgrader.CheckOrigin.
+func AllowOrigins(origins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if u.Host == r.Host {
+			return true
+		}
+
+		return slices.ContainsFunc(origins, func(allowed string) bool {
+			return allowed == u.Scheme+"://"+u.Host
+		})
+	}
+}
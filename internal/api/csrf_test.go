@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newCSRFTestHandler() http.Handler {
+	api := &API{CSRF: CSRFSettings{
+		Key:            []byte(strings.Repeat("k", 32)),
+		TrustedOrigins: []string{"https://app.gobid.example"},
+	}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /csrf-token", api.HandleGetCSRFToken)
+	mux.HandleFunc("POST /products", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	return api.csrfProtect()(mux)
+}
+
+func TestCSRFProtectRequiresToken(t *testing.T) {
+	handler := newCSRFTestHandler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/csrf-token", nil))
+
+	var body struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body.CSRFToken == "" {
+		t.Fatalf("expected a token, got %q (%v)", body.CSRFToken, err)
+	}
+	cookies := w.Result().Cookies()
+
+	post := func(token, origin string) int {
+		r := httptest.NewRequest(http.MethodPost, "/products", nil)
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+		if token != "" {
+			r.Header.Set("X-CSRF-Token", token)
+		}
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := post("", ""); code != http.StatusForbidden {
+		t.Errorf("expected a request without token to be forbidden, got %d", code)
+	}
+	if code := post(body.CSRFToken, ""); code != http.StatusCreated {
+		t.Errorf("expected a request with the token to pass, got %d", code)
+	}
+	if code := post(body.CSRFToken, "https://app.gobid.example"); code != http.StatusCreated {
+		t.Errorf("expected a trusted origin to pass, got %d", code)
+	}
+	if code := post(body.CSRFToken, "https://evil.example"); code != http.StatusForbidden {
+		t.Errorf("expected an untrusted origin to be forbidden, got %d", code)
+	}
+}
+
+func TestAllowOrigins(t *testing.T) {
+	allow := AllowOrigins([]string{"https://app.gobid.example"})
+
+	for origin, want := range map[string]bool{
+		"":                           true,
+		"http://example.com":         true,
+		"https://app.gobid.example":  true,
+		"http://app.gobid.example":   false,
+		"https://evil.example":       false,
+		"https://app.gobid.example.": false,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+
+		if got := allow(r); got != want {
+			t.Errorf("origin %q: expected %t, got %t", origin, want, got)
+		}
+	}
+}
@@ -0,0 +1,565 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/services"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/memstore"
+	"github.com/FelipeBelloDultra/go-bid/internal/use-case/product"
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// messageTimeout bounds the wait for a WebSocket message in end-to-end tests.
+const messageTimeout = 2 * time.Second
+
+// testServer is the whole API, routes included, served over HTTP against the
+// in-memory store. Auction time follows Clock, which tests advance to end
+// auctions; sessions and connections run on the wall clock.
+type testServer struct {
+	t      *testing.T
+	URL    string
+	API    *API
+	Clock  *clock.Fake
+	Store  *memstore.Store
+	Server *httptest.Server
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	// Sessions store the user ID with gob, as registered by main.
+	gob.Register(uuid.UUID{})
+
+	// Starting on a whole microsecond keeps auctions starting "now" from
+	// being rounded into the future by the store.
+	clk := clock.NewFake(time.Now().Truncate(time.Microsecond))
+
+	store := memstore.NewWithClock(clk)
+	api := &API{
+		Router:            chi.NewMux(),
+		Sessions:          scs.New(),
+		Clock:             clk,
+		UserService:       services.NewUserService(store),
+		ProductService:    services.NewProductService(store, clk),
+		BidsService:       services.NewBidsService(store, clk, services.SoftClose{}),
+		SettlementService: services.NewSettlementService(store, clk),
+		ProductRules:      product.CreateProductRules{Clock: clk, MinAuctionDuration: 2 * time.Hour},
+		CSRF:              CSRFSettings{Key: []byte(strings.Repeat("k", 32))},
+		WsUpgrader:        websocket.Upgrader{CheckOrigin: AllowOrigins(nil)},
+		AuctionLobby: services.AuctionLobby{
+			Rooms:     make(map[uuid.UUID]*services.AuctionRoom),
+			Scheduled: make(map[uuid.UUID]clock.Timer),
+		},
+	}
+	api.BindRoutes()
+
+	server := httptest.NewServer(api.Router)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), messageTimeout)
+		defer cancel()
+
+		if err := api.Shutdown(ctx); err != nil {
+			t.Errorf("failed to shut down: %v", err)
+		}
+		server.Close()
+	})
+
+	return &testServer{
+		t:      t,
+		URL:    server.URL + "/api/v1",
+		API:    api,
+		Clock:  clk,
+		Store:  store,
+		Server: server,
+	}
+}
+
+func (s *testServer) subscribeURL(productId uuid.UUID) string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "/products/ws/subscribe/" + productId.String()
+}
+
+// testUser is a signed-up user with its own cookie jar, holding its session
+// and CSRF cookies.
+type testUser struct {
+	server    *testServer
+	ID        uuid.UUID
+	jar       http.CookieJar
+	client    *http.Client
+	csrfToken string
+}
+
+// signUp creates a user through the API and signs it in.
+func (s *testServer) signUp(name string) *testUser {
+	s.t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		s.t.Fatalf("failed to create cookie jar: %v", err)
+	}
+	user := &testUser{server: s, jar: jar, client: &http.Client{Jar: jar}}
+
+	email := name + "@gobid.test"
+	var created struct {
+		ID uuid.UUID `json:"id"`
+	}
+	user.do(http.MethodPost, "/users/sign-up", map[string]any{
+		"user_name": name,
+		"email":     email,
+		"password":  "password123",
+		"bio":       "a user of the end-to-end tests",
+	}, http.StatusCreated, &created)
+	user.ID = created.ID
+
+	var token struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	user.do(http.MethodGet, "/csrf-token", nil, http.StatusOK, &token)
+	user.csrfToken = token.CSRFToken
+
+	user.do(http.MethodPost, "/users/sign-in", map[string]any{
+		"email":    email,
+		"password": "password123",
+	}, http.StatusOK, nil)
+
+	return user
+}
+
+// do sends a JSON request and decodes the response into out, failing the test
+// unless it has the wanted status. It returns the raw response body.
+func (u *testUser) do(method, path string, body any, wantStatus int, out any) []byte {
+	u.server.t.Helper()
+
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			u.server.t.Fatalf("failed to encode request: %v", err)
+		}
+	}
+
+	r, err := http.NewRequest(method, u.server.URL+path, &reader)
+	if err != nil {
+		u.server.t.Fatalf("failed to build request: %v", err)
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if u.csrfToken != "" {
+		r.Header.Set("X-CSRF-Token", u.csrfToken)
+	}
+
+	res, err := u.client.Do(r)
+	if err != nil {
+		u.server.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		u.server.t.Fatalf("%s %s: failed to read response: %v", method, path, err)
+	}
+	if res.StatusCode != wantStatus {
+		u.server.t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, wantStatus, res.StatusCode, raw)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			u.server.t.Fatalf("%s %s: failed to decode response: %v", method, path, err)
+		}
+	}
+
+	return raw
+}
+
+// createProduct lists a product and returns its ID.
+func (u *testUser) createProduct(fields map[string]any) uuid.UUID {
+	u.server.t.Helper()
+
+	var created struct {
+		ProductID uuid.UUID `json:"product_id"`
+	}
+	u.do(http.MethodPost, "/products/", fields, http.StatusCreated, &created)
+
+	return created.ProductID
+}
+
+// testConn is a WebSocket subscription to an auction room. It keeps every
+// message it received, as sent by the server.
+type testConn struct {
+	t        *testing.T
+	user     *testUser
+	conn     *websocket.Conn
+	received [][]byte
+}
+
+func (u *testUser) subscribe(productId uuid.UUID) *testConn {
+	u.server.t.Helper()
+
+	dialer := websocket.Dialer{Jar: u.jar, HandshakeTimeout: messageTimeout}
+	conn, res, err := dialer.Dial(u.server.subscribeURL(productId), nil)
+	if err != nil {
+		if res != nil {
+			body, _ := io.ReadAll(res.Body)
+			u.server.t.Fatalf("failed to subscribe (status %d): %v: %s", res.StatusCode, err, body)
+		}
+		u.server.t.Fatalf("failed to subscribe: %v", err)
+	}
+	u.server.t.Cleanup(func() { conn.Close() })
+
+	// Advancing the clock makes the server ping, possibly right before it
+	// closes the connection, so failing to answer is not an error.
+	conn.SetPingHandler(func(data string) error {
+		conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(messageTimeout))
+		return nil
+	})
+
+	return &testConn{t: u.server.t, user: u, conn: conn}
+}
+
+func (c *testConn) send(m services.Message) {
+	c.t.Helper()
+
+	if err := c.conn.WriteJSON(m); err != nil {
+		c.t.Fatalf("failed to send message: %v", err)
+	}
+}
+
+func (c *testConn) placeBid(amount int64) {
+	c.t.Helper()
+
+	c.send(services.Message{Kind: services.PlaceBid, Amount: amount, Currency: "USD"})
+}
+
+// expect reads the next messages and checks that their kinds are exactly
+// kinds, in order.
+func (c *testConn) expect(kinds ...services.MessageKind) []services.Message {
+	c.t.Helper()
+
+	messages := make([]services.Message, 0, len(kinds))
+	for _, kind := range kinds {
+		c.conn.SetReadDeadline(time.Now().Add(messageTimeout))
+
+		_, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			c.t.Fatalf("expected message kind %d, got error: %v", kind, err)
+		}
+		c.received = append(c.received, raw)
+
+		var m services.Message
+		if err := json.Unmarshal(raw, &m); err != nil {
+			c.t.Fatalf("expected message kind %d, got invalid JSON %s: %v", kind, raw, err)
+		}
+		if m.Kind != kind {
+			c.t.Fatalf("expected message kind %d, got %+v", kind, m)
+		}
+		messages = append(messages, m)
+	}
+
+	return messages
+}
+
+// expectClosed checks that the server closes the connection, with nothing
+// left to read, using the given close code.
+func (c *testConn) expectClosed(code int) {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(messageTimeout))
+
+	var m services.Message
+	err := c.conn.ReadJSON(&m)
+	var closeError *websocket.CloseError
+	if !errors.As(err, &closeError) {
+		c.t.Fatalf("expected the connection to close, got %+v (%v)", m, err)
+	}
+	if closeError.Code != code {
+		c.t.Fatalf("expected close code %d, got %d", code, closeError.Code)
+	}
+}
+
+func TestBiddingEndToEnd(t *testing.T) {
+	server := newTestServer(t)
+
+	seller := server.signUp("seller")
+	alice := server.signUp("alice")
+	bob := server.signUp("bob")
+
+	auctionEnd := server.Clock.Now().Add(3 * time.Hour)
+	productId := seller.createProduct(map[string]any{
+		"product_name":   "gramophone",
+		"description":    "an auction run end to end",
+		"base_price":     map[string]any{"amount": 1000, "currency": "USD"},
+		"auction_end":    auctionEnd,
+		"bid_increments": []map[string]any{{"from": 0, "increment": 100}},
+	})
+
+	aliceConn := alice.subscribe(productId)
+	state := aliceConn.expect(services.AuctionState)[0]
+	if state.Amount != 1000 || !state.AuctionEnd.Equal(auctionEnd.Round(time.Microsecond)) {
+		t.Fatalf("unexpected auction state %+v", state)
+	}
+	bobConn := bob.subscribe(productId)
+	bobConn.expect(services.AuctionState)
+
+	aliceConn.placeBid(2000)
+	aliceConn.expect(services.SuccessfullyPlacedBid, services.NewBidPlaced)
+	if placed := bobConn.expect(services.NewBidPlaced)[0]; placed.Amount != 2000 {
+		t.Fatalf("expected bob to see a bid of 2000, got %d", placed.Amount)
+	}
+
+	bobConn.placeBid(2050)
+	if failed := bobConn.expect(services.FailedToPlaceBid)[0]; failed.Amount != 2100 {
+		t.Fatalf("expected the minimum acceptable bid to be 2100, got %+v", failed)
+	}
+
+	// The user_id a client sends is ignored: bob bids as himself.
+	bobConn.send(services.Message{Kind: services.PlaceBid, Amount: 2500, Currency: "USD", UserID: alice.ID})
+	if own := bobConn.expect(services.SuccessfullyPlacedBid, services.NewBidPlaced)[1]; own.UserID != bob.ID {
+		t.Fatalf("expected bob's bid to be announced to him as his, got %+v", own)
+	}
+	if placed := aliceConn.expect(services.NewBidPlaced)[0]; placed.Amount != 2500 {
+		t.Fatalf("expected alice to see a bid of 2500, got %d", placed.Amount)
+	}
+
+	server.Clock.Advance(3 * time.Hour)
+	for _, conn := range []*testConn{aliceConn, bobConn} {
+		finished := conn.expect(services.AuctionFinished)[0]
+		if finished.UserID != bob.ID || finished.Amount != 2500 {
+			t.Fatalf("expected bob to win at 2500, got %+v", finished)
+		}
+		conn.expectClosed(websocket.CloseNormalClosure)
+	}
+
+	anonymous := websocket.Dialer{HandshakeTimeout: messageTimeout}
+	if _, res, err := anonymous.Dial(server.subscribeURL(productId), nil); err == nil || res == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected subscribing without a session to be unauthorized, got %v", err)
+	}
+
+	var result struct {
+		WinnerID   uuid.UUID `json:"winner_id"`
+		FinalPrice int64     `json:"final_price"`
+		Status     string    `json:"status"`
+	}
+	seller.do(http.MethodGet, "/products/"+productId.String()+"/result", nil, http.StatusOK, &result)
+	if result.Status != services.AuctionResultSold || result.WinnerID != bob.ID || result.FinalPrice != 2500 {
+		t.Fatalf("unexpected auction result %+v", result)
+	}
+}
+
+func TestDutchPriceStopsAtPublicFloor(t *testing.T) {
+	server := newTestServer(t)
+	seller := server.signUp("seller")
+
+	fields := map[string]any{
+		"product_name":        "tulip bulbs",
+		"description":         "a dutch auction with a floor price",
+		"base_price":          map[string]any{"amount": 9000, "currency": "USD"},
+		"auction_end":         server.Clock.Now().Add(3 * time.Hour),
+		"auction_type":        "dutch",
+		"price_drop":          map[string]any{"amount": 1000, "currency": "USD"},
+		"price_drop_interval": 60,
+	}
+	var problems map[string]string
+	seller.do(http.MethodPost, "/products/", fields, http.StatusUnprocessableEntity, &problems)
+	if _, ok := problems["floor_price"]; !ok || len(problems) != 1 {
+		t.Fatalf("expected dutch auctions to require a floor price, got %v", problems)
+	}
+
+	fields["floor_price"] = map[string]any{"amount": 6500, "currency": "USD"}
+	productId := seller.createProduct(fields)
+
+	server.Clock.Advance(10 * time.Minute)
+	var listing struct {
+		FloorPrice   int64 `json:"floor_price"`
+		CurrentPrice int64 `json:"current_price"`
+	}
+	seller.do(http.MethodGet, "/products/"+productId.String(), nil, http.StatusOK, &listing)
+	if listing.FloorPrice != 6500 || listing.CurrentPrice != 6500 {
+		t.Fatalf("expected the price to stop at the public floor of 6500, got %+v", listing)
+	}
+
+	var page struct {
+		Products []struct {
+			ID           uuid.UUID `json:"id"`
+			CurrentPrice int64     `json:"current_price"`
+		} `json:"products"`
+	}
+	seller.do(http.MethodGet, "/products/?min_price=6500", nil, http.StatusOK, &page)
+	if len(page.Products) != 1 || page.Products[0].ID != productId || page.Products[0].CurrentPrice != 6500 {
+		t.Fatalf("expected the listing to price the auction at its floor, got %+v", page.Products)
+	}
+}
+
+func TestCreateProductUsesConfiguredMinimumDuration(t *testing.T) {
+	server := newTestServer(t)
+	server.API.ProductRules.MinAuctionDuration = 4 * time.Hour
+
+	seller := server.signUp("seller")
+
+	var problems map[string]string
+	seller.do(http.MethodPost, "/products/", map[string]any{
+		"product_name": "hourglass",
+		"description":  "an auction shorter than the configured minimum",
+		"base_price":   map[string]any{"amount": 1000, "currency": "USD"},
+		"auction_end":  server.Clock.Now().Add(3 * time.Hour),
+	}, http.StatusUnprocessableEntity, &problems)
+	if want := "this field must be at least 4h0m0s after auction_start"; len(problems) != 1 || problems["auction_end"] != want {
+		t.Fatalf("expected only the auction_end problem %q, got %v", want, problems)
+	}
+}
+
+func TestCreateProductValidatesDatesAgainstAuctionClock(t *testing.T) {
+	server := newTestServer(t)
+	server.Clock.Advance(24 * time.Hour)
+
+	seller := server.signUp("seller")
+	fields := func(auctionEnd time.Time) map[string]any {
+		return map[string]any{
+			"product_name": "pocket watch",
+			"description":  "an auction dated by the auction clock",
+			"base_price":   map[string]any{"amount": 1000, "currency": "USD"},
+			"auction_end":  auctionEnd,
+		}
+	}
+
+	var problems map[string]string
+	seller.do(http.MethodPost, "/products/", fields(time.Now().Add(3*time.Hour)), http.StatusUnprocessableEntity, &problems)
+	if _, ok := problems["auction_end"]; !ok {
+		t.Fatalf("expected an auction ending before the auction clock's now to be rejected, got %v", problems)
+	}
+
+	seller.createProduct(fields(server.Clock.Now().Add(3 * time.Hour)))
+}
+
+func TestSubscribingDuringShutdownIsRejected(t *testing.T) {
+	server := newTestServer(t)
+
+	seller := server.signUp("seller")
+	alice := server.signUp("alice")
+	bob := server.signUp("bob")
+
+	productId := seller.createProduct(map[string]any{
+		"product_name": "music box",
+		"description":  "an auction cut short by a shutdown",
+		"base_price":   map[string]any{"amount": 1000, "currency": "USD"},
+		"auction_end":  server.Clock.Now().Add(3 * time.Hour),
+	})
+
+	aliceConn := alice.subscribe(productId)
+	aliceConn.expect(services.AuctionState)
+
+	ctx, cancel := context.WithTimeout(context.Background(), messageTimeout)
+	defer cancel()
+	if err := server.API.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shut down: %v", err)
+	}
+	aliceConn.expect(services.ServerGoingAway)
+	aliceConn.expectClosed(websocket.CloseGoingAway)
+
+	dialer := websocket.Dialer{Jar: bob.jar, HandshakeTimeout: messageTimeout}
+	if _, res, err := dialer.Dial(server.subscribeURL(productId), nil); err == nil || res == nil || res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected subscribing during shutdown to be unavailable, got %v", err)
+	}
+}
+
+func TestReservePriceIsNeverDisclosed(t *testing.T) {
+	server := newTestServer(t)
+
+	seller := server.signUp("seller")
+	alice := server.signUp("alice")
+	bob := server.signUp("bob")
+
+	const reserve = 4321
+	auctionEnd := server.Clock.Now().Add(3 * time.Hour)
+	var problems map[string]string
+	seller.do(http.MethodPost, "/products/", map[string]any{
+		"product_name":        "music box",
+		"description":         "a dutch auction with a reserve",
+		"base_price":          map[string]any{"amount": 9000, "currency": "USD"},
+		"reserve_price":       map[string]any{"amount": reserve, "currency": "USD"},
+		"auction_end":         auctionEnd,
+		"auction_type":        "dutch",
+		"price_drop":          map[string]any{"amount": 500, "currency": "USD"},
+		"price_drop_interval": 60,
+		"floor_price":         map[string]any{"amount": 3000, "currency": "USD"},
+	}, http.StatusUnprocessableEntity, &problems)
+	if _, ok := problems["reserve_price"]; !ok || len(problems) != 1 {
+		t.Fatalf("expected dutch auctions to reject a reserve price, got %v", problems)
+	}
+
+	productId := seller.createProduct(map[string]any{
+		"product_name":   "music box",
+		"description":    "an auction with a secret reserve",
+		"base_price":     map[string]any{"amount": 1000, "currency": "USD"},
+		"reserve_price":  map[string]any{"amount": reserve, "currency": "USD"},
+		"auction_end":    auctionEnd,
+		"bid_increments": []map[string]any{{"from": 0, "increment": 100}},
+	})
+
+	aliceConn := alice.subscribe(productId)
+	aliceConn.expect(services.AuctionState)
+	bobConn := bob.subscribe(productId)
+	bobConn.expect(services.AuctionState)
+
+	aliceConn.placeBid(2000)
+	aliceConn.expect(services.SuccessfullyPlacedBid, services.NewBidPlaced)
+	bobConn.expect(services.NewBidPlaced)
+
+	bobConn.placeBid(4400)
+	bobConn.expect(services.SuccessfullyPlacedBid, services.NewBidPlaced, services.ReserveMet)
+	aliceConn.expect(services.NewBidPlaced, services.ReserveMet)
+
+	var outputs [][]byte
+	for _, user := range []*testUser{seller, alice} {
+		outputs = append(outputs,
+			user.do(http.MethodGet, "/products/", nil, http.StatusOK, nil),
+			user.do(http.MethodGet, "/products/"+productId.String(), nil, http.StatusOK, nil),
+			user.do(http.MethodGet, "/products/"+productId.String()+"/bids", nil, http.StatusOK, nil),
+		)
+	}
+	outputs = append(outputs, aliceConn.received...)
+	outputs = append(outputs, bobConn.received...)
+
+	for _, raw := range outputs {
+		var document any
+		if err := json.Unmarshal(raw, &document); err != nil {
+			t.Fatalf("invalid JSON %s: %v", raw, err)
+		}
+		if mentionsReserve(document, reserve) {
+			t.Errorf("expected the reserve to stay secret, got %s", raw)
+		}
+	}
+}
+
+// mentionsReserve reports whether a decoded JSON document has a field named
+// after the reserve price or holds its amount anywhere.
+func mentionsReserve(document any, reserve float64) bool {
+	switch v := document.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if strings.Contains(key, "reserve") || mentionsReserve(value, reserve) {
+				return true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if mentionsReserve(value, reserve) {
+				return true
+			}
+		}
+	case float64:
+		return v == reserve
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsHandler serves the Prometheus metrics on /metrics. It is not
+// authenticated and carries per-auction series, so it is served on an internal
+// address rather than on the API's router.
+func (api *API) MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	return mux
+}
+
+// instrumentRequests records the count and latency of every request under its
+// chi route pattern, so path parameters do not multiply the series.
+func instrumentRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := &hijackTracker{WrapResponseWriter: middleware.NewWrapResponseWriter(w, r.ProtoMajor)}
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		// Hijacked WebSocket connections never report a status, and handlers
+		// that write nothing get an implicit 200.
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+			if ww.hijacked {
+				status = http.StatusSwitchingProtocols
+			}
+		}
+
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// hijackTracker remembers whether the handler took over the connection.
+type hijackTracker struct {
+	middleware.WrapResponseWriter
+	hijacked bool
+}
+
+func (w *hijackTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.WrapResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+
+	return conn, rw, err
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentRequestsLabelsByRoutePattern(t *testing.T) {
+	router := chi.NewMux()
+	router.Use(instrumentRequests)
+	router.Get("/products/{product_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	requests := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/products/{product_id}", "404")
+	before := testutil.ToFloat64(requests)
+
+	for _, path := range []string{"/products/1", "/products/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(requests) - before; got != 2 {
+		t.Fatalf("expected 2 requests under the route pattern, got %v", got)
+	}
+}
+
+// hijackableRecorder is a ResponseRecorder whose connection can be hijacked.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	client.Close()
+
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+func TestInstrumentRequestsReportsUnwrittenStatus(t *testing.T) {
+	router := chi.NewMux()
+	router.Use(instrumentRequests)
+	router.Get("/silent", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/upgrade", func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("failed to hijack the connection: %v", err)
+			return
+		}
+		conn.Close()
+	})
+
+	silent := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/silent", "200")
+	upgrade := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/upgrade", "101")
+	beforeSilent, beforeUpgrade := testutil.ToFloat64(silent), testutil.ToFloat64(upgrade)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/silent", nil))
+	router.ServeHTTP(hijackableRecorder{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/upgrade", nil))
+
+	if got := testutil.ToFloat64(silent) - beforeSilent; got != 1 {
+		t.Errorf("expected a handler that writes nothing to be counted as 200, got %v", got)
+	}
+	if got := testutil.ToFloat64(upgrade) - beforeUpgrade; got != 1 {
+		t.Errorf("expected a hijacked connection to be counted as 101, got %v", got)
+	}
+}
+
+func TestMetricsAreServedApartFromTheAPI(t *testing.T) {
+	server := newTestServer(t)
+
+	res, err := http.Get(server.Server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("failed to request the API's /metrics: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the API not to serve /metrics, got status %d", res.StatusCode)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.API.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "gobid_http_requests_total") {
+		t.Fatalf("expected the metrics handler to serve gobid metrics, got status %d", recorder.Code)
+	}
+}
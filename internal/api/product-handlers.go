@@ -1,18 +1,26 @@
 package api
 
 import (
-	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 
 	jsonutils "github.com/FelipeBelloDultra/go-bid/internal/json-utils"
 	"github.com/FelipeBelloDultra/go-bid/internal/services"
 	"github.com/FelipeBelloDultra/go-bid/internal/use-case/product"
+	"github.com/FelipeBelloDultra/go-bid/internal/validator"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 func (api *API) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
-	data, problems, err := jsonutils.DecodeValidJSON[product.CreateProductReq](r)
-	if err != nil {
+	data, err := jsonutils.DecodeJSON[product.CreateProductReq](r)
+	var problems validator.Evaluator
+	if err == nil {
+		problems = data.Valid(r.Context(), api.ProductRules)
+	}
+	if err != nil || len(problems) > 0 {
 		_ = jsonutils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
 		return
 	}
@@ -24,14 +32,22 @@ func (api *API) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	productId, err := api.ProductService.Create(
-		r.Context(),
-		userID,
-		data.ProductName,
-		data.Description,
-		data.BasePrice,
-		data.AuctionEnd,
-	)
+	productId, err := api.ProductService.Create(r.Context(), services.NewProduct{
+		SellerID:      userID,
+		ProductName:   data.ProductName,
+		Description:   data.Description,
+		BasePrice:     data.BasePrice,
+		ReservePrice:  data.ReservePrice,
+		BuyNowPrice:   data.BuyNowPrice,
+		AuctionStart:  data.AuctionStart,
+		AuctionEnd:    data.AuctionEnd,
+		BidIncrements: data.BidIncrements,
+		AuctionType:   data.AuctionType,
+
+		PriceDrop:         data.PriceDrop,
+		PriceDropInterval: time.Duration(data.PriceDropInterval) * time.Second,
+		FloorPrice:        data.FloorPrice,
+	})
 	if err != nil {
 		_ = jsonutils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]any{
 			"error": "internal server error",
@@ -39,17 +55,96 @@ func (api *API) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, _ := context.WithDeadline(context.Background(), data.AuctionEnd)
-	auctionRoom := services.NewAuctionRoom(ctx, productId, api.BidsService)
+	product, err := api.ProductService.GetProductByID(r.Context(), productId)
+	if err != nil {
+		_ = jsonutils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
 
-	go auctionRoom.Run()
+	scheduled := api.openAuctionRoom(product)
+	err = api.PubSub.Publish(r.Context(), services.AuctionEvent{
+		Kind:      services.AuctionEventOpened,
+		ProductID: productId,
+	})
+	if err != nil {
+		slog.Error("Failed to publish new auction", "auctionID", productId, "error", err)
+	}
 
-	api.AuctionLobby.Lock()
-	api.AuctionLobby.Rooms[productId] = auctionRoom
-	api.AuctionLobby.Unlock()
+	if scheduled {
+		jsonutils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+			"product_id":    productId,
+			"auction_start": product.AuctionStart,
+			"message":       "auction has been scheduled with success",
+		})
+		return
+	}
 
 	jsonutils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
 		"product_id": productId,
 		"message":    "acution has started with success",
 	})
 }
+
+func (api *API) handleListProducts(w http.ResponseWriter, r *http.Request) {
+	data := product.NewListProductsReq(r.URL.Query())
+	if problems := data.Valid(r.Context()); len(problems) > 0 {
+		_ = jsonutils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	page, err := api.ProductService.List(r.Context(), services.ProductFilter{
+		Status:       data.Status,
+		SellerID:     data.SellerID,
+		MinPrice:     data.MinPrice,
+		MaxPrice:     data.MaxPrice,
+		EndingBefore: data.EndingBefore,
+		Search:       data.Search,
+		SortBy:       data.SortBy,
+		Cursor:       data.Cursor,
+		Limit:        data.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			_ = jsonutils.EncodeJSON(w, r, http.StatusUnprocessableEntity, map[string]any{
+				"cursor": "this field must be a cursor returned for the same sort",
+			})
+			return
+		}
+
+		_ = jsonutils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJSON(w, r, http.StatusOK, page)
+}
+
+func (api *API) handleGetProduct(w http.ResponseWriter, r *http.Request) {
+	productId, err := uuid.Parse(chi.URLParam(r, "product_id"))
+	if err != nil {
+		_ = jsonutils.EncodeJSON(w, r, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	listing, err := api.ProductService.GetListingByID(r.Context(), productId)
+	if err != nil {
+		if errors.Is(err, services.ErrProductNotFound) {
+			_ = jsonutils.EncodeJSON(w, r, http.StatusNotFound, map[string]any{
+				"error": "product not found",
+			})
+			return
+		}
+
+		_ = jsonutils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	_ = jsonutils.EncodeJSON(w, r, http.StatusOK, listing)
+}
@@ -10,32 +10,41 @@ func (api *API) BindRoutes() {
 		middleware.RequestID,
 		middleware.Logger,
 		middleware.Recoverer,
-		api.Sessions.LoadAndSave,
+		instrumentRequests,
 	)
 
-	// csrfMiddleware := csrf.Protect(
-	// 	[]byte(os.Getenv("GOBID_CSRF_KEY")),
-	// 	csrf.Secure(false), // Dev only
-	// )
-
-	// api.Router.Use(csrfMiddleware)
+	csrfProtect := api.csrfProtect()
 
 	api.Router.Route("/api", func(r chi.Router) {
+		r.Use(api.Sessions.LoadAndSave)
+
 		r.Route("/v1", func(r chi.Router) {
-			// r.Get("/csrf-token", api.HandleGetCSRFToken)
+			r.With(csrfProtect).Get("/csrf-token", api.HandleGetCSRFToken)
 			r.Route("/users", func(r chi.Router) {
+				// Signing up starts no session, so it cannot be forged on
+				// behalf of a signed-in user.
 				r.Post("/sign-up", api.handleSignUpUser)
-				r.Post("/sign-in", api.handleSignInUser)
+
 				r.Group(func(r chi.Router) {
-					r.Use(api.AuthMiddleware)
-					r.Post("/logout", api.handleLogoutUser)
+					r.Use(csrfProtect)
+					r.Post("/sign-in", api.handleSignInUser)
+					r.Group(func(r chi.Router) {
+						r.Use(api.AuthMiddleware)
+						r.Post("/logout", api.handleLogoutUser)
+					})
 				})
 			})
 
 			r.Route("/products", func(r chi.Router) {
+				r.Use(csrfProtect)
+				r.Get("/", api.handleListProducts)
+				r.Get("/{product_id}", api.handleGetProduct)
+
 				r.Group(func(r chi.Router) {
 					r.Use(api.AuthMiddleware)
 					r.Post("/", api.handleCreateProduct)
+					r.Get("/{product_id}/result", api.handleGetAuctionResult)
+					r.Get("/{product_id}/bids", api.handleListBids)
 
 					r.Get("/ws/subscribe/{product_id}", api.handleSubscribeUserToAuction)
 				})
@@ -0,0 +1,220 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Timers and tickers fire, in
+// order, as Advance or Set move the time past them; AfterFunc callbacks run
+// before Advance returns.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []*fakeTimer
+}
+
+var _ Clock = (*Fake)(nil)
+
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.changed = sync.NewCond(&f.mu)
+
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// Advance moves the clock forward by d, firing every timer due by then.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t, firing every timer due by then. The clock never
+// goes backwards.
+func (f *Fake) Set(t time.Time) {
+	for {
+		f.mu.Lock()
+		next := f.nextDue(t)
+		if next == nil {
+			if t.After(f.now) {
+				f.now = t
+			}
+			f.mu.Unlock()
+			return
+		}
+
+		if next.when.After(f.now) {
+			f.now = next.when
+		}
+		fn := next.fire(f.now)
+		f.mu.Unlock()
+
+		if fn != nil {
+			fn()
+		}
+	}
+}
+
+// BlockUntil waits until at least n timers and tickers are armed, so a test
+// can be sure the code under test is waiting on the clock before advancing
+// it.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for f.armed() < n {
+		f.changed.Wait()
+	}
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.arm(&fakeTimer{clock: f, c: make(chan time.Time, 1)}, d)
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	return fakeTicker{f.arm(&fakeTimer{clock: f, c: make(chan time.Time, 1), period: d}, d)}
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	return f.arm(&fakeTimer{clock: f, fn: fn}, d)
+}
+
+func (f *Fake) arm(t *fakeTimer, d time.Duration) *fakeTimer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t.schedule(d)
+	return t
+}
+
+// nextDue returns the armed timer due first, if it is due by t.
+func (f *Fake) nextDue(t time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, timer := range f.timers {
+		if timer.when.After(t) {
+			continue
+		}
+		if next == nil || timer.when.Before(next.when) {
+			next = timer
+		}
+	}
+
+	return next
+}
+
+func (f *Fake) armed() int {
+	return len(f.timers)
+}
+
+func (f *Fake) remove(t *fakeTimer) bool {
+	for i, timer := range f.timers {
+		if timer == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			f.changed.Broadcast()
+			return true
+		}
+	}
+
+	return false
+}
+
+// fakeTimer backs the timers, tickers and AfterFunc calls of a Fake. Its
+// fields are guarded by the clock's mutex.
+type fakeTimer struct {
+	clock  *Fake
+	c      chan time.Time
+	fn     func()
+	period time.Duration
+	when   time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.clock.remove(t)
+	t.drain()
+	t.schedule(d)
+
+	return active
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.drain()
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) schedule(d time.Duration) {
+	if t.period > 0 && d > 0 {
+		t.period = d
+	}
+
+	t.when = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+	t.clock.changed.Broadcast()
+}
+
+// fire delivers the tick for now and rearms tickers. It returns the AfterFunc
+// callback to run once the clock is unlocked.
+func (t *fakeTimer) fire(now time.Time) func() {
+	t.clock.remove(t)
+	if t.period > 0 {
+		t.when = t.when.Add(t.period)
+		t.clock.timers = append(t.clock.timers, t)
+	}
+
+	if t.fn != nil {
+		return t.fn
+	}
+
+	select {
+	case t.c <- now:
+	default:
+	}
+	return nil
+}
+
+func (t *fakeTimer) drain() {
+	if t.c == nil {
+		return
+	}
+
+	select {
+	case <-t.c:
+	default:
+	}
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.fakeTimer.Reset(d)
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
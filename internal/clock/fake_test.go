@@ -0,0 +1,84 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeFiresTimersInOrder(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFake(start)
+
+	var fired []string
+	clock.AfterFunc(2*time.Hour, func() { fired = append(fired, "late") })
+	clock.AfterFunc(time.Hour, func() { fired = append(fired, "early:"+clock.Now().Format(time.TimeOnly)) })
+	timer := clock.NewTimer(90 * time.Minute)
+
+	clock.Advance(59 * time.Minute)
+	if len(fired) != 0 {
+		t.Fatalf("expected nothing to fire yet, got %v", fired)
+	}
+
+	clock.Advance(time.Hour)
+	if len(fired) != 1 || fired[0] != "early:13:00:00" {
+		t.Fatalf("expected the early callback to run at 13:00, got %v", fired)
+	}
+	select {
+	case at := <-timer.C():
+		if want := start.Add(90 * time.Minute); !at.Equal(want) {
+			t.Errorf("expected the timer to fire at %s, got %s", want, at)
+		}
+	default:
+		t.Fatal("expected the timer to have fired")
+	}
+	if now := clock.Now(); !now.Equal(start.Add(119 * time.Minute)) {
+		t.Errorf("expected the clock to stop at the requested time, got %s", now)
+	}
+
+	clock.Advance(time.Minute)
+	if len(fired) != 2 {
+		t.Fatalf("expected the late callback to run, got %v", fired)
+	}
+}
+
+func TestFakeTimerResetDiscardsPendingTick(t *testing.T) {
+	clock := NewFake(time.Now())
+	timer := clock.NewTimer(time.Second)
+
+	clock.Advance(time.Second)
+	if timer.Reset(time.Minute) {
+		t.Error("expected Reset to report a timer that had already fired")
+	}
+	select {
+	case <-timer.C():
+		t.Fatal("expected the pending tick to be discarded")
+	default:
+	}
+
+	if !timer.Stop() {
+		t.Error("expected Stop to report an armed timer")
+	}
+	clock.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("expected a stopped timer not to fire")
+	default:
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	clock := NewFake(time.Now())
+	ticker := clock.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for i := range 3 {
+		clock.Advance(time.Minute)
+		select {
+		case <-ticker.C():
+		default:
+			t.Fatalf("expected tick %d", i+1)
+		}
+	}
+
+	clock.BlockUntil(1)
+}
@@ -0,0 +1,320 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config holds every setting of the API server. Settings are read from
+// command-line flags, then GOBID_* environment variables (a .env file is
+// loaded into the environment when present), then defaults.
+type Config struct {
+	ListenAddr      string
+	MetricsAddr     string
+	ShutdownTimeout time.Duration
+	Database        DatabaseConfig
+	Session         SessionConfig
+	CSRF            CSRFConfig
+	WebSocket       WebSocketConfig
+	Auction         AuctionConfig
+}
+
+// DatabaseConfig locates the Postgres database. URL, when set, takes
+// precedence over the individual connection parameters.
+type DatabaseConfig struct {
+	URL      string
+	Host     string
+	Port     int
+	Name     string
+	User     string
+	Password string
+}
+
+type SessionConfig struct {
+	Lifetime     time.Duration
+	CookieSecure bool
+	SameSite     string
+}
+
+type CSRFConfig struct {
+	Key            string
+	Secure         bool
+	TrustedOrigins []string
+}
+
+type WebSocketConfig struct {
+	AllowedOrigins []string
+	MaxMessageSize int64
+	ReadDeadline   time.Duration
+	WriteWait      time.Duration
+	SendQueueSize  int
+	OverflowPolicy string
+}
+
+type AuctionConfig struct {
+	MinDuration        time.Duration
+	SoftCloseWindow    time.Duration
+	SoftCloseExtension time.Duration
+}
+
+// Load reads the configuration from args (without the program name), the
+// environment and a .env file in the working directory, and validates it.
+func Load(args []string) (Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, fmt.Errorf("failed to load .env: %w", err)
+	}
+
+	env := envReader{}
+	var cfg Config
+
+	fs := flag.NewFlagSet("gobid", flag.ContinueOnError)
+	fs.StringVar(&cfg.ListenAddr, "addr", env.string("GOBID_LISTEN_ADDR", "localhost:3333"), "address to listen on")
+	fs.StringVar(&cfg.MetricsAddr, "metrics-addr", env.string("GOBID_METRICS_ADDR", "localhost:3334"), "internal address to serve /metrics on; empty disables it")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", env.duration("GOBID_SHUTDOWN_TIMEOUT", 15*time.Second), "time to drain clients on shutdown")
+
+	fs.StringVar(&cfg.Database.URL, "database-url", env.string("GOBID_DATABASE_URL", ""), "Postgres connection URL")
+	fs.StringVar(&cfg.Database.Host, "database-host", env.string("GOBID_DATABASE_HOST", "localhost"), "Postgres host")
+	fs.IntVar(&cfg.Database.Port, "database-port", env.int("GOBID_DATABASE_PORT", 5432), "Postgres port")
+	fs.StringVar(&cfg.Database.Name, "database-name", env.string("GOBID_DATABASE_NAME", ""), "Postgres database name")
+	fs.StringVar(&cfg.Database.User, "database-user", env.string("GOBID_DATABASE_USER", ""), "Postgres user")
+	cfg.Database.Password = env.string("GOBID_DATABASE_PASSWORD", "")
+
+	fs.DurationVar(&cfg.Session.Lifetime, "session-lifetime", env.duration("GOBID_SESSION_LIFETIME", 24*time.Hour), "session lifetime")
+	fs.BoolVar(&cfg.Session.CookieSecure, "session-cookie-secure", env.bool("GOBID_SESSION_COOKIE_SECURE", false), "only send the session cookie over HTTPS")
+	fs.StringVar(&cfg.Session.SameSite, "session-same-site", env.string("GOBID_SESSION_SAME_SITE", "lax"), "SameSite mode of the session cookie: lax, strict or none")
+
+	cfg.CSRF.Key = env.string("GOBID_CSRF_KEY", "")
+	fs.BoolVar(&cfg.CSRF.Secure, "csrf-secure", env.bool("GOBID_CSRF_SECURE", false), "only send the CSRF cookie over HTTPS")
+	trustedOrigins := env.string("GOBID_CSRF_TRUSTED_ORIGINS", "")
+	fs.StringVar(&trustedOrigins, "csrf-trusted-origins", trustedOrigins, "comma-separated origins, besides the API's own, allowed to send unsafe requests")
+
+	allowedOrigins := env.string("GOBID_WS_ALLOWED_ORIGINS", "")
+	fs.StringVar(&allowedOrigins, "ws-allowed-origins", allowedOrigins, "comma-separated origins allowed to open WebSockets")
+	fs.Int64Var(&cfg.WebSocket.MaxMessageSize, "ws-max-message-size", env.int64("GOBID_WS_MAX_MESSAGE_SIZE", 512), "maximum size in bytes of a WebSocket message")
+	fs.DurationVar(&cfg.WebSocket.ReadDeadline, "ws-read-deadline", env.duration("GOBID_WS_READ_DEADLINE", 60*time.Second), "time a WebSocket may stay silent before it is dropped")
+	fs.DurationVar(&cfg.WebSocket.WriteWait, "ws-write-wait", env.duration("GOBID_WS_WRITE_WAIT", 10*time.Second), "time allowed to write a WebSocket message")
+	fs.IntVar(&cfg.WebSocket.SendQueueSize, "ws-send-queue-size", env.int("GOBID_WS_SEND_QUEUE_SIZE", 512), "messages that may wait to be written to a WebSocket client")
+	fs.StringVar(&cfg.WebSocket.OverflowPolicy, "ws-overflow-policy", env.string("GOBID_WS_OVERFLOW_POLICY", "coalesce"), "what gives when a client's send queue is full: coalesce, drop_oldest or disconnect")
+
+	fs.DurationVar(&cfg.Auction.MinDuration, "auction-min-duration", env.duration("GOBID_AUCTION_MIN_DURATION", 2*time.Hour), "minimum duration of an auction")
+	fs.DurationVar(&cfg.Auction.SoftCloseWindow, "soft-close-window", env.duration("GOBID_SOFT_CLOSE_WINDOW", 2*time.Minute), "window before the end in which bids extend an auction")
+	fs.DurationVar(&cfg.Auction.SoftCloseExtension, "soft-close-extension", env.duration("GOBID_SOFT_CLOSE_EXTENSION", 2*time.Minute), "time a late bid adds to an auction")
+
+	if err := errors.Join(env.errs...); err != nil {
+		return Config{}, err
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg.CSRF.TrustedOrigins = splitOrigins(trustedOrigins)
+	cfg.WebSocket.AllowedOrigins = splitOrigins(allowedOrigins)
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.ListenAddr != "", "listen address cannot be blank")
+	check(c.MetricsAddr != c.ListenAddr, "metrics address must differ from the listen address")
+	check(c.ShutdownTimeout > 0, "shutdown timeout must be positive")
+
+	if c.Database.URL == "" {
+		check(c.Database.Host != "", "database host cannot be blank")
+		check(c.Database.Port > 0 && c.Database.Port <= 65535, "database port must be between 1 and 65535")
+		check(c.Database.Name != "", "database name cannot be blank")
+		check(c.Database.User != "", "database user cannot be blank")
+	}
+
+	check(c.Session.Lifetime > 0, "session lifetime must be positive")
+	_, err := c.Session.SameSiteMode()
+	check(err == nil, "%v", err)
+	check(
+		c.Session.SameSite != "none" || c.Session.CookieSecure,
+		"session cookies with SameSite none must be secure",
+	)
+
+	check(len(c.CSRF.Key) == 32, "CSRF key must be 32 bytes long")
+	for _, origin := range slices.Concat(c.CSRF.TrustedOrigins, c.WebSocket.AllowedOrigins) {
+		u, err := url.Parse(origin)
+		check(
+			err == nil && u.Scheme != "" && u.Host != "" && u.Path == "",
+			"origin %q must be a scheme and host, e.g. https://gobid.example",
+			origin,
+		)
+	}
+	check(c.WebSocket.MaxMessageSize > 0, "WebSocket max message size must be positive")
+	check(c.WebSocket.ReadDeadline > 0, "WebSocket read deadline must be positive")
+	check(c.WebSocket.WriteWait > 0, "WebSocket write wait must be positive")
+	check(c.WebSocket.SendQueueSize > 0, "WebSocket send queue size must be positive")
+	check(
+		slices.Contains([]string{"coalesce", "drop_oldest", "disconnect"}, c.WebSocket.OverflowPolicy),
+		"WebSocket overflow policy must be coalesce, drop_oldest or disconnect, got %q",
+		c.WebSocket.OverflowPolicy,
+	)
+
+	check(c.Auction.MinDuration > 0, "minimum auction duration must be positive")
+	check(c.Auction.SoftCloseWindow >= 0, "soft-close window cannot be negative")
+	check(c.Auction.SoftCloseExtension >= 0, "soft-close extension cannot be negative")
+
+	return errors.Join(errs...)
+}
+
+// DSN is the connection string given to pgxpool.
+func (d DatabaseConfig) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+
+	return fmt.Sprintf(
+		"user=%s password=%s host=%s port=%d dbname=%s",
+		d.User,
+		d.Password,
+		d.Host,
+		d.Port,
+		d.Name,
+	)
+}
+
+func (s SessionConfig) SameSiteMode() (http.SameSite, error) {
+	switch s.SameSite {
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("session SameSite must be lax, strict or none, got %q", s.SameSite)
+	}
+}
+
+// LogValue summarizes the configuration for the startup log, leaving secrets
+// out.
+func (c Config) LogValue() slog.Value {
+	database := fmt.Sprintf("%s@%s:%d/%s", c.Database.User, c.Database.Host, c.Database.Port, c.Database.Name)
+	if c.Database.URL != "" {
+		database = "url"
+		if u, err := url.Parse(c.Database.URL); err == nil {
+			database = u.Redacted()
+		}
+	}
+
+	return slog.GroupValue(
+		slog.String("listen_addr", c.ListenAddr),
+		slog.String("metrics_addr", c.MetricsAddr),
+		slog.Duration("shutdown_timeout", c.ShutdownTimeout),
+		slog.String("database", database),
+		slog.Duration("session_lifetime", c.Session.Lifetime),
+		slog.Bool("session_cookie_secure", c.Session.CookieSecure),
+		slog.String("session_same_site", c.Session.SameSite),
+		slog.Bool("csrf_secure", c.CSRF.Secure),
+		slog.Any("csrf_trusted_origins", c.CSRF.TrustedOrigins),
+		slog.Any("ws_allowed_origins", c.WebSocket.AllowedOrigins),
+		slog.Int64("ws_max_message_size", c.WebSocket.MaxMessageSize),
+		slog.Duration("ws_read_deadline", c.WebSocket.ReadDeadline),
+		slog.Duration("ws_write_wait", c.WebSocket.WriteWait),
+		slog.Int("ws_send_queue_size", c.WebSocket.SendQueueSize),
+		slog.String("ws_overflow_policy", c.WebSocket.OverflowPolicy),
+		slog.Duration("auction_min_duration", c.Auction.MinDuration),
+		slog.Duration("soft_close_window", c.Auction.SoftCloseWindow),
+		slog.Duration("soft_close_extension", c.Auction.SoftCloseExtension),
+	)
+}
+
+// splitOrigins splits a comma-separated list of origins, dropping blanks and
+// trailing slashes.
+func splitOrigins(value string) []string {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return origins
+}
+
+// envReader reads typed environment variables, collecting the ones that fail
+// to parse.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) string(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func (e *envReader) parse(key string, parse func(string) error) {
+	value := e.string(key, "")
+	if value == "" {
+		return
+	}
+
+	if err := parse(value); err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s: %w", key, err))
+	}
+}
+
+func (e *envReader) duration(key string, fallback time.Duration) time.Duration {
+	e.parse(key, func(value string) (err error) {
+		fallback, err = time.ParseDuration(value)
+		return err
+	})
+
+	return fallback
+}
+
+func (e *envReader) int(key string, fallback int) int {
+	e.parse(key, func(value string) (err error) {
+		fallback, err = strconv.Atoi(value)
+		return err
+	})
+
+	return fallback
+}
+
+func (e *envReader) int64(key string, fallback int64) int64 {
+	e.parse(key, func(value string) (err error) {
+		fallback, err = strconv.ParseInt(value, 10, 64)
+		return err
+	})
+
+	return fallback
+}
+
+func (e *envReader) bool(key string, fallback bool) bool {
+	e.parse(key, func(value string) (err error) {
+		fallback, err = strconv.ParseBool(value)
+		return err
+	})
+
+	return fallback
+}
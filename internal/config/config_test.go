@@ -0,0 +1,85 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrefersFlagsOverEnvironment(t *testing.T) {
+	t.Setenv("GOBID_DATABASE_NAME", "gobid")
+	t.Setenv("GOBID_DATABASE_USER", "gobid")
+	t.Setenv("GOBID_CSRF_KEY", strings.Repeat("k", 32))
+	t.Setenv("GOBID_LISTEN_ADDR", ":8080")
+	t.Setenv("GOBID_SESSION_LIFETIME", "1h")
+	t.Setenv("GOBID_WS_ALLOWED_ORIGINS", "https://gobid.example, http://localhost:5173")
+
+	cfg, err := Load([]string{"-addr", ":9090"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.ListenAddr != ":9090" {
+		t.Errorf("expected the flag to win, got listen address %q", cfg.ListenAddr)
+	}
+	if cfg.Session.Lifetime != time.Hour {
+		t.Errorf("expected the session lifetime from the environment, got %s", cfg.Session.Lifetime)
+	}
+	if cfg.MetricsAddr != "localhost:3334" {
+		t.Errorf("expected the default metrics address, got %q", cfg.MetricsAddr)
+	}
+	if cfg.Auction.MinDuration != 2*time.Hour {
+		t.Errorf("expected the default minimum auction duration, got %s", cfg.Auction.MinDuration)
+	}
+	if got := strings.Join(cfg.WebSocket.AllowedOrigins, " "); got != "https://gobid.example http://localhost:5173" {
+		t.Errorf("unexpected allowed origins %q", got)
+	}
+}
+
+func TestLoadReportsEveryInvalidSetting(t *testing.T) {
+	t.Setenv("GOBID_DATABASE_NAME", "")
+	t.Setenv("GOBID_DATABASE_USER", "gobid")
+
+	_, err := Load([]string{
+		"-session-same-site", "none",
+		"-ws-allowed-origins", "gobid.example",
+		"-csrf-trusted-origins", "https://gobid.example/app",
+		"-ws-max-message-size", "0",
+		"-addr", ":3333",
+		"-metrics-addr", ":3333",
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, want := range []string{
+		"database name cannot be blank",
+		"SameSite none must be secure",
+		`origin "gobid.example"`,
+		`origin "https://gobid.example/app"`,
+		"CSRF key must be 32 bytes long",
+		"max message size must be positive",
+		"metrics address must differ from the listen address",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+}
+
+func TestLoadRejectsMalformedEnvironment(t *testing.T) {
+	t.Setenv("GOBID_SHUTDOWN_TIMEOUT", "soon")
+
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid GOBID_SHUTDOWN_TIMEOUT") {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+}
+
+func TestLogValueOmitsPassword(t *testing.T) {
+	cfg := Config{Database: DatabaseConfig{URL: "postgres://gobid:hunter2@db:5432/gobid"}}
+
+	if summary := cfg.LogValue().String(); strings.Contains(summary, "hunter2") {
+		t.Fatalf("summary leaks the password: %s", summary)
+	}
+}
@@ -0,0 +1,140 @@
+// Package metrics holds the Prometheus collectors served by API.MetricsHandler.
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "gobid"
+
+var (
+	// HTTPRequests counts the requests served per chi route pattern.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent serving HTTP requests, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	AuctionRooms = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "auction_rooms",
+		Help:      "Auction rooms running on this instance.",
+	})
+
+	// AuctionRoomClients is labeled by product ID; the series of a room is
+	// removed when the room stops.
+	AuctionRoomClients = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "auction_room_clients",
+		Help:      "WebSocket clients connected to an auction room.",
+	}, []string{"product_id"})
+
+	// Bids counts bidding requests (place_bid, set_max_bid, buy_now and
+	// accept_price) by outcome and, for rejected ones, reason.
+	Bids = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bids_total",
+		Help:      "Bidding requests handled, by request, outcome and rejection reason.",
+	}, []string{"request", "outcome", "reason"})
+
+	// SendQueueDepth samples how many messages are already waiting for a
+	// client whenever a new one is queued for it.
+	SendQueueDepth = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "websocket_send_queue_depth",
+		Help:      "Messages waiting in a client's send queue when a new one is queued.",
+		Buckets:   []float64{0, 1, 2, 4, 8, 16, 32, 64, 128, 256, 512},
+	})
+
+	// DroppedMessages counts the messages dropped from full client queues,
+	// by overflow policy.
+	DroppedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_dropped_messages_total",
+		Help:      "Messages dropped from full client send queues, by overflow policy.",
+	}, []string{"policy"})
+
+	EvictedClients = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_evicted_clients_total",
+		Help:      "WebSocket clients disconnected for not keeping up with their send queue.",
+	})
+)
+
+// RegisterPool exposes the statistics of pool.
+func RegisterPool(pool *pgxpool.Pool) {
+	prometheus.MustRegister(poolCollector{pool: pool})
+}
+
+var (
+	poolAcquiredConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "acquired_conns"),
+		"Connections currently acquired from the pool.", nil, nil,
+	)
+	poolIdleConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "idle_conns"),
+		"Idle connections in the pool.", nil, nil,
+	)
+	poolTotalConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "total_conns"),
+		"Connections in the pool, including those being established.", nil, nil,
+	)
+	poolMaxConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "max_conns"),
+		"Maximum size of the pool.", nil, nil,
+	)
+	poolAcquires = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "acquires_total"),
+		"Successful connection acquisitions.", nil, nil,
+	)
+	poolEmptyAcquires = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "empty_acquires_total"),
+		"Acquisitions that had to wait for a connection.", nil, nil,
+	)
+	poolCanceledAcquires = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "canceled_acquires_total"),
+		"Acquisitions canceled by their context.", nil, nil,
+	)
+	poolAcquireDuration = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "acquire_duration_seconds_total"),
+		"Total time spent acquiring connections.", nil, nil,
+	)
+)
+
+// poolCollector reads the pgxpool statistics on every scrape.
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+	ch <- poolAcquires
+	ch <- poolEmptyAcquires
+	ch <- poolCanceledAcquires
+	ch <- poolAcquireDuration
+}
+
+func (c poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
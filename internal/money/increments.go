@@ -0,0 +1,83 @@
+package money
+
+// IncrementBand sets the minimum raise, in minor units, for prices starting
+// at From (inclusive).
+type IncrementBand struct {
+	From      int64 `json:"from"`
+	Increment int64 `json:"increment"`
+}
+
+// IncrementSchedule lists increment bands ordered by From. A single band is a
+// fixed increment; several bands make a tiered schedule.
+type IncrementSchedule []IncrementBand
+
+// defaultIncrementBands are the default bands for currencies with two
+// decimals.
+var defaultIncrementBands = IncrementSchedule{
+	{From: 0, Increment: 5},
+	{From: 100, Increment: 25},
+	{From: 500, Increment: 50},
+	{From: 2_500, Increment: 100},
+	{From: 10_000, Increment: 250},
+	{From: 25_000, Increment: 500},
+	{From: 50_000, Increment: 1_000},
+	{From: 100_000, Increment: 2_500},
+	{From: 250_000, Increment: 5_000},
+	{From: 500_000, Increment: 10_000},
+}
+
+// DefaultIncrementSchedule is the tiered schedule used when a product does
+// not configure its own. The bands are scaled by the currency's minor unit,
+// so a currency without decimals, such as JPY, gets bands a hundred times
+// larger than a currency with two.
+func DefaultIncrementSchedule(currency string) IncrementSchedule {
+	scale := int64(1)
+	for range 2 - minorUnits[currency] {
+		scale *= 10
+	}
+
+	schedule := make(IncrementSchedule, len(defaultIncrementBands))
+	for i, band := range defaultIncrementBands {
+		schedule[i] = IncrementBand{From: band.From * scale, Increment: band.Increment * scale}
+	}
+
+	return schedule
+}
+
+// Valid reports whether the schedule starts at zero, has strictly ascending
+// bands and only positive increments.
+func (s IncrementSchedule) Valid() bool {
+	if len(s) == 0 || s[0].From != 0 {
+		return false
+	}
+
+	for i, band := range s {
+		if band.Increment <= 0 {
+			return false
+		}
+		if i > 0 && band.From <= s[i-1].From {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IncrementAt returns the minimum raise over price. An empty schedule allows
+// raises of a single minor unit.
+func (s IncrementSchedule) IncrementAt(price int64) int64 {
+	increment := int64(1)
+	for _, band := range s {
+		if band.From > price {
+			break
+		}
+		increment = band.Increment
+	}
+
+	return increment
+}
+
+// NextMinimum is the lowest acceptable bid over price.
+func (s IncrementSchedule) NextMinimum(price int64) int64 {
+	return price + s.IncrementAt(price)
+}
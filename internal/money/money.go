@@ -0,0 +1,41 @@
+package money
+
+import "errors"
+
+// Money is an exact monetary value: Amount is expressed in the currency's
+// minor unit (cents for USD) and Currency is an ISO 4217 code.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// minorUnits maps the supported ISO 4217 codes to their number of decimals.
+var minorUnits = map[string]int{
+	"BRL": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"CHF": 2,
+	"ARS": 2,
+	"MXN": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"CLP": 0,
+}
+
+func IsValidCurrency(code string) bool {
+	_, ok := minorUnits[code]
+	return ok
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == other.Currency
+}
@@ -2,11 +2,16 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/metrics"
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -20,87 +25,594 @@ const (
 	NewBidPlaced
 	AuctionFinished
 	InvalidJSON
+	AuctionExtended
+	SetMaxBid
+	SuccessfullySetMaxBid
+	FailedToSetMaxBid
+	MaxBidExceeded
+	ReserveMet
+	BuyNow
+	FailedToBuyNow
+	PriceDropped
+	AcceptPrice
+	FailedToAcceptPrice
+	AuctionState
+	ServerGoingAway
 )
 
+// Message is the WebSocket wire format. Amount is expressed in the minor unit
+// (cents) of Currency. Events broadcast to the whole room carry an increasing
+// Sequence, which a reconnecting client hands back to replay what it missed.
+// Messages sent to a single client carry none and are not replayed.
 type Message struct {
-	Message string      `json:"message,omitempty"`
-	Amount  float64     `json:"amount,omitempty"`
-	Kind    MessageKind `json:"kind"`
-	UserID  uuid.UUID   `json:"user_id,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	Amount     int64       `json:"amount,omitempty"`
+	Currency   string      `json:"currency,omitempty"`
+	Kind       MessageKind `json:"kind"`
+	UserID     uuid.UUID   `json:"user_id,omitempty"`
+	AuctionEnd *time.Time  `json:"auction_end,omitempty"`
+	BidCount   int64       `json:"bid_count,omitempty"`
+	Sequence   uint64      `json:"sequence,omitempty"`
+	Stream     string      `json:"stream,omitempty"`
 }
 
+// AuctionLobby holds the running rooms and the timers of the auctions that
+// are scheduled to start later. Once Closed, no more rooms are opened.
 type AuctionLobby struct {
 	sync.Mutex
-	Rooms map[uuid.UUID]*AuctionRoom
+	Rooms     map[uuid.UUID]*AuctionRoom
+	Scheduled map[uuid.UUID]clock.Timer
+	Closed    bool
 }
 
 type AuctionRoom struct {
-	ID          uuid.UUID
-	Context     context.Context
-	Broadcast   chan Message
-	Unregister  chan *Client
-	Register    chan *Client
-	Clients     map[uuid.UUID]*Client
-	BidsService BidsService
+	ID                uuid.UUID
+	AuctionType       pgstore.AuctionType
+	Currency          string
+	AuctionEnd        time.Time
+	AskingPrice       int64
+	Context           context.Context
+	Broadcast         chan Message
+	Unregister        chan *Client
+	Register          chan *Client
+	Clients           map[uuid.UUID]*Client
+	BidsService       BidsService
+	SettlementService SettlementService
+	// PubSub replicates the room's events to other instances; it may be nil
+	// when a single instance serves every client.
+	PubSub *PubSubService
+	// Stream identifies this replica of the room. Sequence numbers are only
+	// meaningful within a stream.
+	Stream string
+
+	product       pgstore.Product
+	clock         clock.Clock
+	cancel        context.CancelFunc
+	endedEarly    bool
+	endedRemotely bool
+	deadline      clock.Timer
+	priceDrop     clock.Timer
+	done          chan struct{}
+
+	sequence uint64
+	history  []broadcastRecord
+
+	// events holds the events published by the room's replicas until the room
+	// applies them; eventsReady signals that there are some.
+	eventsMu    sync.Mutex
+	events      []AuctionEvent
+	eventsReady chan struct{}
+}
+
+// broadcastRecord is a broadcast kept for replays, along with the function
+// that adapted it for each recipient.
+type broadcastRecord struct {
+	message     Message
+	personalize func(uuid.UUID, Message) Message
 }
 
+// messageFor returns the version of the broadcast meant for userId.
+func (b broadcastRecord) messageFor(userId uuid.UUID) Message {
+	if b.personalize == nil {
+		return b.message
+	}
+
+	return b.personalize(userId, b.message)
+}
+
+// Done is closed once the room has stopped processing messages.
+func (r *AuctionRoom) Done() <-chan struct{} {
+	return r.done
+}
+
+// registerClient adds c to the room, replays the events broadcast since the
+// client's LastSequence and then sends it the current state of the auction.
 func (r *AuctionRoom) registerClient(c *Client) {
 	slog.Info("New user connected", "Client", c)
 	r.Clients[c.UserID] = c
+	metrics.AuctionRoomClients.WithLabelValues(r.ID.String()).Set(float64(len(r.Clients)))
+
+	if c.LastSequence > 0 {
+		for _, record := range r.history {
+			if record.message.Sequence <= c.LastSequence {
+				continue
+			}
+			r.send(c, record.messageFor(c.UserID))
+		}
+	}
+
+	state := Message{
+		Kind:       AuctionState,
+		Message:    "current auction state",
+		Currency:   r.Currency,
+		AuctionEnd: &r.AuctionEnd,
+		Sequence:   r.sequence,
+		Stream:     r.Stream,
+	}
+
+	highBid, bidCount, err := r.BidsService.GetBidStats(r.Context, r.ID)
+	if err != nil {
+		slog.Error("Failed to load auction state", "RoomID", r.ID, "error", err)
+		state.Amount = r.product.BasePrice
+	} else {
+		state.Amount = currentPrice(r.product, highBid, bidCount, r.clock.Now())
+		state.BidCount = bidCount
+	}
+	if r.AuctionType == pgstore.AuctionTypeDutch && bidCount == 0 {
+		state.Amount = r.AskingPrice
+	}
+
+	r.send(c, state)
+}
+
+// broadcast numbers m and sends it to every client, keeping it for replays.
+// personalize may adapt the message for a given recipient; replays adapt it
+// the same way. Every client gets a version of every broadcast, so the
+// sequence a client sees only has gaps when messages were dropped.
+func (r *AuctionRoom) broadcast(m Message, personalize func(uuid.UUID, Message) Message) {
+	r.sequence++
+	m.Sequence = r.sequence
+
+	record := broadcastRecord{message: m, personalize: personalize}
+	r.history = append(r.history, record)
+	if len(r.history) > historySize {
+		r.history = r.history[len(r.history)-historySize:]
+	}
+
+	for id, client := range r.Clients {
+		r.send(client, record.messageFor(id))
+	}
 }
 
 func (r *AuctionRoom) unregisterClient(c *Client) {
 	slog.Info("User disconnected", "Client", c)
+	// A client that has been evicted or replaced by a newer connection of the
+	// same user is no longer in the room.
+	if r.Clients[c.UserID] == c {
+		r.removeClient(c)
+	}
+}
+
+func (r *AuctionRoom) removeClient(c *Client) {
 	delete(r.Clients, c.UserID)
+	metrics.AuctionRoomClients.WithLabelValues(r.ID.String()).Set(float64(len(r.Clients)))
 }
 
 func (r *AuctionRoom) broadcastMessage(m Message) {
 	slog.Info("New message received", "RoomID", r.ID, "Message", m.Message, "UserID", m.UserID)
 	switch m.Kind {
 	case PlaceBid:
-		bid, err := r.BidsService.PlaceBid(r.Context, r.ID, m.UserID, m.Amount)
+		placed, err := r.BidsService.PlaceBid(
+			r.Context,
+			r.ID,
+			m.UserID,
+			money.Money{Amount: m.Amount, Currency: m.Currency},
+		)
+		observeBid("place_bid", err)
 		if err != nil {
-			if errors.Is(err, ErrBidIsTooLow) {
-				if client, ok := r.Clients[m.UserID]; ok {
-					client.Send <- Message{Kind: FailedToPlaceBid, Message: ErrBidIsTooLow.Error(), UserID: m.UserID}
-				}
-				return
-			}
+			r.sendFailure(FailedToPlaceBid, m.UserID, "failed to place bid", err)
+			return
 		}
 
 		if client, ok := r.Clients[m.UserID]; ok {
-			client.Send <- Message{Kind: SuccessfullyPlacedBid, Message: "your bid was successfully placed", UserID: m.UserID}
+			r.send(client, Message{Kind: SuccessfullyPlacedBid, Message: "your bid was successfully placed", UserID: m.UserID})
 		}
 
-		for id, client := range r.Clients {
-			newBidMessage := Message{
-				Kind:    NewBidPlaced,
-				Message: "a new bid was placed",
-				Amount:  bid.BidAmount,
-			}
-			if id == m.UserID {
-				continue
-			}
-			client.Send <- newBidMessage
+		r.announcePlacedBid(placed, true)
+		r.publish(AuctionEvent{Kind: AuctionEventBidsPlaced, Placed: &placed, FirstByHand: true})
+	case SetMaxBid:
+		placed, err := r.BidsService.SetMaxBid(
+			r.Context,
+			r.ID,
+			m.UserID,
+			money.Money{Amount: m.Amount, Currency: m.Currency},
+		)
+		observeBid("set_max_bid", err)
+		if err != nil {
+			r.sendFailure(FailedToSetMaxBid, m.UserID, "failed to set max bid", err)
+			return
+		}
+
+		if client, ok := r.Clients[m.UserID]; ok {
+			r.send(client, Message{
+				Kind:     SuccessfullySetMaxBid,
+				Message:  "your max bid was successfully set",
+				Amount:   m.Amount,
+				Currency: r.Currency,
+				UserID:   m.UserID,
+			})
+		}
+
+		r.announcePlacedBid(placed, false)
+		if len(placed.Bids) > 0 {
+			r.publish(AuctionEvent{Kind: AuctionEventBidsPlaced, Placed: &placed})
 		}
+	case BuyNow:
+		_, err := r.BidsService.BuyNow(r.Context, r.ID, m.UserID)
+		observeBid("buy_now", err)
+		if err != nil {
+			r.sendFailure(FailedToBuyNow, m.UserID, "failed to buy now", err)
+			return
+		}
+
+		r.endEarly()
+	case AcceptPrice:
+		_, err := r.BidsService.AcceptPrice(r.Context, r.ID, m.UserID)
+		observeBid("accept_price", err)
+		if err != nil {
+			r.sendFailure(FailedToAcceptPrice, m.UserID, "failed to accept price", err)
+			return
+		}
+
+		r.endEarly()
 	case InvalidJSON:
 		client, ok := r.Clients[m.UserID]
 		if !ok {
 			slog.Info("Client not found in hashmap", "userId", m.UserID)
 			return
 		}
-		client.Send <- m
+		r.send(client, m)
+	}
+}
+
+// Deliver queues an event published by another replica of the room. It never
+// waits for the room, so a busy room does not hold up the events of others.
+func (r *AuctionRoom) Deliver(event AuctionEvent) {
+	r.eventsMu.Lock()
+	r.events = append(r.events, event)
+	r.eventsMu.Unlock()
+
+	select {
+	case r.eventsReady <- struct{}{}:
+	default:
+	}
+}
+
+// takeEvents returns the delivered events in order and empties the queue.
+func (r *AuctionRoom) takeEvents() []AuctionEvent {
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+
+	events := r.events
+	r.events = nil
+
+	return events
+}
+
+// handleEvent applies an event published by another replica of the room.
+func (r *AuctionRoom) handleEvent(event AuctionEvent) {
+	switch event.Kind {
+	case AuctionEventBidsPlaced:
+		if event.Placed != nil {
+			r.announcePlacedBid(*event.Placed, event.FirstByHand)
+		}
+	case AuctionEventPriceDropped:
+		r.announcePrice(event.Price)
+	case AuctionEventEnded:
+		r.endedRemotely = true
+		r.endEarly()
+	}
+}
+
+// publish hands event to the other replicas of the room.
+func (r *AuctionRoom) publish(event AuctionEvent) {
+	event.ProductID = r.ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := r.PubSub.Publish(ctx, event); err != nil {
+		slog.Error("Failed to publish auction event", "auctionID", r.ID, "kind", event.Kind, "error", err)
+	}
+}
+
+// sendFailure reports a rejected request to its sender, with the minimum
+// acceptable amount when the bid was too low. Unexpected errors are logged and
+// replaced by fallback so internals do not leak to clients.
+func (r *AuctionRoom) sendFailure(kind MessageKind, userId uuid.UUID, fallback string, err error) {
+	failedMessage := Message{Kind: kind, Message: err.Error(), UserID: userId}
+
+	var minimumBidError *MinimumBidError
+	if errors.As(err, &minimumBidError) {
+		failedMessage.Amount = minimumBidError.Minimum.Amount
+		failedMessage.Currency = minimumBidError.Minimum.Currency
+	}
+
+	if rejectionReason(err) == rejectionInternal {
+		slog.Error(fallback, "RoomID", r.ID, "UserID", userId, "error", err)
+		failedMessage.Message = fallback
+	}
+
+	if client, ok := r.Clients[userId]; ok {
+		r.send(client, failedMessage)
+	}
+}
+
+// rejectionReasons names the errors that reject a bidding request on its
+// merits. Their messages are shown to the bidder.
+var rejectionReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrBidIsTooLow, "bid_too_low"},
+	{ErrBidIncrementTooSmall, "bid_increment_too_small"},
+	{ErrMaxBidIsTooLow, "max_bid_too_low"},
+	{ErrAuctionEnded, "auction_ended"},
+	{ErrAuctionNotStarted, "auction_not_started"},
+	{ErrBuyNowUnavailable, "buy_now_unavailable"},
+	{ErrUnsupportedByAuction, "unsupported_by_auction"},
+	{money.ErrCurrencyMismatch, "currency_mismatch"},
+}
+
+const rejectionInternal = "internal"
+
+func rejectionReason(err error) string {
+	for _, rejection := range rejectionReasons {
+		if errors.Is(err, rejection.err) {
+			return rejection.reason
+		}
+	}
+
+	return rejectionInternal
+}
+
+// observeBid counts a bidding request by its outcome.
+func observeBid(request string, err error) {
+	if err == nil {
+		metrics.Bids.WithLabelValues(request, "accepted", "").Inc()
+		return
+	}
+
+	metrics.Bids.WithLabelValues(request, "rejected", rejectionReason(err)).Inc()
+}
+
+// announcePlacedBid tells every client about the bids that were stored. When
+// firstByHand is set the first bid was placed by hand and is announced to its
+// bidder as theirs; the other bids are proxy bids, announced to their owner as
+// placed on their behalf. Bidders whose maximum was exceeded are notified on
+// their own and everyone learns when the (secret) reserve price has been
+// reached. In sealed auctions the amount is kept from everyone but the bidder.
+func (r *AuctionRoom) announcePlacedBid(placed PlacedBid, firstByHand bool) {
+	sealed := IsSealedAuction(r.AuctionType)
+	for i, bid := range placed.Bids {
+		newBidMessage := Message{
+			Kind:     NewBidPlaced,
+			Message:  "a new bid was placed",
+			Amount:   bid.BidAmount,
+			Currency: r.Currency,
+		}
+		if sealed {
+			newBidMessage.Message = "a new sealed bid was placed"
+			newBidMessage.Amount = 0
+			newBidMessage.Currency = ""
+		}
+
+		r.broadcast(newBidMessage, func(id uuid.UUID, m Message) Message {
+			if id != bid.BidderID {
+				return m
+			}
+
+			m.Message = "a bid was placed on your behalf"
+			if i == 0 && firstByHand {
+				m.Message = "your bid was placed"
+			}
+			m.Amount = bid.BidAmount
+			m.Currency = r.Currency
+			m.UserID = id
+			return m
+		})
+	}
+
+	for _, id := range placed.Exceeded {
+		if client, ok := r.Clients[id]; ok {
+			r.send(client, Message{
+				Kind:    MaxBidExceeded,
+				Message: "your max bid has been exceeded",
+				UserID:  id,
+			})
+		}
+	}
+
+	if placed.ReserveMet {
+		r.broadcast(Message{
+			Kind:    ReserveMet,
+			Message: "the reserve price has been met",
+		}, nil)
+	}
+
+	if placed.Extended {
+		r.extendAuction(placed.AuctionEnd)
+	}
+}
+
+// extendAuction moves the room's deadline to auctionEnd and tells every client
+// about the new end time.
+func (r *AuctionRoom) extendAuction(auctionEnd time.Time) {
+	slog.Info("Auction has been extended", "auctionID", r.ID, "auctionEnd", auctionEnd)
+
+	r.AuctionEnd = auctionEnd
+	r.armDeadline()
+
+	r.broadcast(Message{
+		Kind:       AuctionExtended,
+		Message:    "auction has been extended",
+		AuctionEnd: &auctionEnd,
+	}, nil)
+}
+
+// armDeadline sets the deadline timer to the end of the auction. Replicas
+// that do not lead wait a grace period longer, leaving the settlement to the
+// leader unless it fails to publish it.
+func (r *AuctionRoom) armDeadline() {
+	wait := r.AuctionEnd.Sub(r.clock.Now())
+	if !r.PubSub.IsLeader() {
+		wait += followerGracePeriod
+	}
+
+	r.deadline.Reset(wait)
+}
+
+// schedulePriceDrops returns the channel of the next price drop of a dutch
+// auction, or nil when the room has no price to drop or does not lead.
+func (r *AuctionRoom) schedulePriceDrops() <-chan time.Time {
+	if r.AuctionType != pgstore.AuctionTypeDutch {
+		return nil
+	}
+
+	if !r.PubSub.IsLeader() {
+		r.priceDrop.Stop()
+		return nil
+	}
+
+	return r.dropPrice()
+}
+
+// announcePrice tells every client about a new asking price.
+func (r *AuctionRoom) announcePrice(price int64) {
+	if price == r.AskingPrice {
+		return
+	}
+
+	slog.Info("Asking price has dropped", "auctionID", r.ID, "price", price)
+
+	r.AskingPrice = price
+	r.broadcast(Message{
+		Kind:     PriceDropped,
+		Message:  "asking price has dropped",
+		Amount:   price,
+		Currency: r.Currency,
+	}, nil)
+}
+
+// dropPrice announces and publishes the current asking price of a dutch
+// auction when it has changed and schedules the next drop. It returns the
+// channel of the next drop, or nil once the price has reached its floor.
+func (r *AuctionRoom) dropPrice() <-chan time.Time {
+	now := r.clock.Now()
+	if price := DutchPrice(r.product, now); price != r.AskingPrice {
+		r.announcePrice(price)
+		r.publish(AuctionEvent{Kind: AuctionEventPriceDropped, Price: price})
+	}
+
+	next, ok := NextDutchPriceDrop(r.product, now)
+	if !ok {
+		return nil
+	}
+
+	r.priceDrop.Reset(next.Sub(now))
+	return r.priceDrop.C()
+}
+
+// Stop makes Run return without finishing the auction, telling every client
+// that the server is going away. The auction carries on once it is restored.
+func (r *AuctionRoom) Stop() {
+	r.cancel()
+}
+
+func (r *AuctionRoom) goAway() {
+	for _, client := range r.Clients {
+		r.send(client, Message{
+			Kind:    ServerGoingAway,
+			Message: "server going away",
+		})
+	}
+}
+
+// endEarly cancels the room's context so Run finishes the auction before its
+// deadline, e.g. after a buy-now purchase.
+func (r *AuctionRoom) endEarly() {
+	r.endedEarly = true
+	r.cancel()
+}
+
+// finishAuction settles the auction, tells every client about the outcome and
+// publishes it to the other replicas. It reports false, leaving the room
+// running, when the auction turns out to have been extended elsewhere.
+func (r *AuctionRoom) finishAuction() bool {
+	finishedMessage := Message{
+		Kind:    AuctionFinished,
+		Message: "auction has been finished",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), settlementTimeout)
+	defer cancel()
+
+	result, err := r.SettlementService.Settle(ctx, r.ID)
+
+	var notEndedError *AuctionNotEndedError
+	if errors.As(err, &notEndedError) {
+		r.extendAuction(notEndedError.AuctionEnd)
+		return false
+	}
+
+	slog.Info("Auction has ended", "auctionID", r.ID)
+	if err != nil {
+		slog.Error("Failed to settle auction", "auctionID", r.ID, "error", err)
+	} else {
+		switch result.Status {
+		case AuctionResultSold, AuctionResultBoughtNow:
+			finishedMessage.Amount = result.FinalPrice.Int64
+			finishedMessage.Currency = result.Currency
+			finishedMessage.UserID = result.WinnerID.Bytes
+			if result.Status == AuctionResultBoughtNow {
+				finishedMessage.Message = "auction has been finished by a buy it now purchase"
+			}
+		case AuctionResultReserveNotMet:
+			finishedMessage.Message = "auction has been finished without meeting the reserve price"
+		default:
+			finishedMessage.Message = "auction has been finished without a winner"
+		}
+	}
+
+	r.broadcast(finishedMessage, nil)
+
+	if !r.endedRemotely {
+		r.publish(AuctionEvent{Kind: AuctionEventEnded})
 	}
+
+	return true
 }
 
 func (r *AuctionRoom) Run() {
 	slog.Info("Auction has begun", "auctionID", r.ID)
+	metrics.AuctionRooms.Inc()
+	r.deadline = r.clock.NewTimer(0)
+	r.priceDrop = r.clock.NewTimer(0)
 	defer func() {
-		close(r.Broadcast)
-		close(r.Register)
-		close(r.Unregister)
+		metrics.AuctionRooms.Dec()
+		metrics.AuctionRoomClients.DeleteLabelValues(r.ID.String())
+		r.deadline.Stop()
+		r.priceDrop.Stop()
+		r.cancel()
+		close(r.done)
 	}()
 
+	r.armDeadline()
+	// priceDrops stays nil, and never fires, unless this replica drops the
+	// price of a dutch auction.
+	priceDrops := r.schedulePriceDrops()
+	leadershipChanged := r.PubSub.LeadershipChanged()
+
 	for {
 		select {
 		case client := <-r.Register:
@@ -109,29 +621,63 @@ func (r *AuctionRoom) Run() {
 			r.unregisterClient(client)
 		case message := <-r.Broadcast:
 			r.broadcastMessage(message)
+		case <-r.eventsReady:
+			for _, event := range r.takeEvents() {
+				r.handleEvent(event)
+			}
+		case <-leadershipChanged:
+			leadershipChanged = r.PubSub.LeadershipChanged()
+			r.armDeadline()
+			priceDrops = r.schedulePriceDrops()
+		case <-priceDrops:
+			priceDrops = r.dropPrice()
+		case <-r.deadline.C():
+			if r.finishAuction() {
+				return
+			}
 		case <-r.Context.Done():
-			slog.Info("Auction has ended", "auctionID", r.ID)
-
-			for _, client := range r.Clients {
-				client.Send <- Message{
-					Kind:    AuctionFinished,
-					Message: "auction has been finished",
-				}
+			if r.endedEarly {
+				r.finishAuction()
+				return
 			}
+
+			slog.Info("Auction room has been stopped", "auctionID", r.ID)
+			r.goAway()
 			return
 		}
 	}
 }
 
-func NewAuctionRoom(ctx context.Context, id uuid.UUID, bidsService BidsService) *AuctionRoom {
+func NewAuctionRoom(
+	ctx context.Context,
+	product pgstore.Product,
+	bidsService BidsService,
+	settlementService SettlementService,
+	pubSub *PubSubService,
+	clk clock.Clock,
+) *AuctionRoom {
+	ctx, cancel := context.WithCancel(ctx)
+
 	return &AuctionRoom{
-		ID:          id,
-		Broadcast:   make(chan Message),
-		Register:    make(chan *Client),
-		Unregister:  make(chan *Client),
-		Clients:     make(map[uuid.UUID]*Client),
-		Context:     ctx,
-		BidsService: bidsService,
+		ID:                product.ID,
+		AuctionType:       product.AuctionType,
+		Currency:          product.Currency,
+		AuctionEnd:        product.AuctionEnd,
+		AskingPrice:       product.BasePrice,
+		Broadcast:         make(chan Message),
+		Register:          make(chan *Client),
+		Unregister:        make(chan *Client),
+		Clients:           make(map[uuid.UUID]*Client),
+		Context:           ctx,
+		BidsService:       bidsService,
+		SettlementService: settlementService,
+		PubSub:            pubSub,
+		eventsReady:       make(chan struct{}, 1),
+		Stream:            uuid.NewString(),
+		product:           product,
+		clock:             clk,
+		cancel:            cancel,
+		done:              make(chan struct{}),
 	}
 }
 
@@ -140,61 +686,274 @@ type Client struct {
 	Conn   *websocket.Conn
 	UserID uuid.UUID
 	Send   chan Message
+	// LastSequence is the last broadcast a reconnecting client has seen; the
+	// events after it are replayed on register.
+	LastSequence uint64
+
+	limits  ClientLimits
+	evicted chan struct{}
+}
+
+// OverflowPolicy decides what happens when a message is sent to a client
+// whose queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowCoalesce drops the queued updates superseded by a later one,
+	// e.g. older prices, and disconnects the client if that is not enough.
+	OverflowCoalesce OverflowPolicy = "coalesce"
+	// OverflowDropOldest drops the oldest queued messages.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDisconnect disconnects the client.
+	OverflowDisconnect OverflowPolicy = "disconnect"
+)
+
+// ClientLimits bounds a client's WebSocket connection. Zero fields fall back
+// to DefaultClientLimits.
+type ClientLimits struct {
+	// MaxMessageSize is the largest message, in bytes, a client may send.
+	MaxMessageSize int64
+	// ReadDeadline is how long a client may stay silent, pongs included,
+	// before it is dropped. Pings are sent at 90% of it.
+	ReadDeadline time.Duration
+	// WriteWait is the time allowed to write a message to the client.
+	WriteWait time.Duration
+	// SendQueueSize is how many messages may wait to be written to the
+	// client before OverflowPolicy applies.
+	SendQueueSize  int
+	OverflowPolicy OverflowPolicy
+}
+
+var DefaultClientLimits = ClientLimits{
+	MaxMessageSize: 512,
+	ReadDeadline:   60 * time.Second,
+	WriteWait:      10 * time.Second,
+	SendQueueSize:  512,
+	OverflowPolicy: OverflowCoalesce,
+}
+
+func (l ClientLimits) withDefaults() ClientLimits {
+	if l.MaxMessageSize <= 0 {
+		l.MaxMessageSize = DefaultClientLimits.MaxMessageSize
+	}
+	if l.ReadDeadline <= 0 {
+		l.ReadDeadline = DefaultClientLimits.ReadDeadline
+	}
+	if l.WriteWait <= 0 {
+		l.WriteWait = DefaultClientLimits.WriteWait
+	}
+	if l.SendQueueSize <= 0 {
+		l.SendQueueSize = DefaultClientLimits.SendQueueSize
+	}
+	if l.OverflowPolicy == "" {
+		l.OverflowPolicy = DefaultClientLimits.OverflowPolicy
+	}
+
+	return l
+}
+
+func (l ClientLimits) pingPeriod() time.Duration {
+	return (l.ReadDeadline * 9) / 10
 }
 
-func NewClient(room *AuctionRoom, conn *websocket.Conn, userId uuid.UUID) *Client {
+func NewClient(room *AuctionRoom, conn *websocket.Conn, userId uuid.UUID, limits ClientLimits) *Client {
+	limits = limits.withDefaults()
+
 	return &Client{
-		Room:   room,
-		Conn:   conn,
-		Send:   make(chan Message, 512),
-		UserID: userId,
+		Room:    room,
+		Conn:    conn,
+		Send:    make(chan Message, limits.SendQueueSize),
+		UserID:  userId,
+		limits:  limits,
+		evicted: make(chan struct{}),
 	}
 }
 
 const (
-	maxMessageSize = 512
-	readDeadline   = 60 * time.Second
-	writeWait      = 10 * time.Second
-	pingPeriod     = (readDeadline * 9) / 10
+	settlementTimeout = 10 * time.Second
+	publishTimeout    = 5 * time.Second
+	// followerGracePeriod is how long a replica that does not lead waits past
+	// the end of an auction for the leader to settle it.
+	followerGracePeriod = 30 * time.Second
+	// historySize is how many broadcasts a room keeps for replays. Clients
+	// that missed more still get the current state on register.
+	historySize = 256
 )
 
+// send queues m for c without ever blocking the room. When c's queue is full
+// its overflow policy decides what gives: the oldest messages, superseded
+// updates or the client itself. Clients notice dropped broadcasts by a gap in
+// the sequence and may reconnect with their last sequence to replay them.
+func (r *AuctionRoom) send(c *Client, m Message) {
+	metrics.SendQueueDepth.Observe(float64(len(c.Send)))
+
+	select {
+	case c.Send <- m:
+		return
+	default:
+	}
+
+	switch c.limits.OverflowPolicy {
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-c.Send:
+				metrics.DroppedMessages.WithLabelValues(string(OverflowDropOldest)).Inc()
+			default:
+			}
+
+			select {
+			case c.Send <- m:
+				return
+			default:
+			}
+		}
+	case OverflowCoalesce:
+		if r.coalesce(c, m) {
+			return
+		}
+	}
+
+	r.evict(c)
+}
+
+// coalesce queues m after dropping the messages superseded by a later one of
+// the same kind, such as older prices. Messages addressed to the client, which
+// carry its UserID, are never dropped. It reports false, queueing nothing,
+// when the queue would still overflow.
+func (r *AuctionRoom) coalesce(c *Client, m Message) bool {
+	// Only the room writes to c.Send, so what is drained here can be put back
+	// in order while the client keeps reading.
+	queued := make([]Message, 0, cap(c.Send)+1)
+drain:
+	for {
+		select {
+		case queuedMessage := <-c.Send:
+			queued = append(queued, queuedMessage)
+		default:
+			break drain
+		}
+	}
+	queued = append(queued, m)
+
+	latest := make(map[MessageKind]int)
+	for i, queuedMessage := range queued {
+		if isSupersededByLatest(queuedMessage.Kind) {
+			latest[queuedMessage.Kind] = i
+		}
+	}
+
+	kept := make([]Message, 0, len(queued))
+	for i, queuedMessage := range queued {
+		isPersonal := queuedMessage.UserID != uuid.Nil
+		if !isPersonal && isSupersededByLatest(queuedMessage.Kind) && latest[queuedMessage.Kind] != i {
+			continue
+		}
+		kept = append(kept, queuedMessage)
+	}
+
+	if len(kept) > cap(c.Send) {
+		return false
+	}
+
+	metrics.DroppedMessages.WithLabelValues(string(OverflowCoalesce)).Add(float64(len(queued) - len(kept)))
+	for _, keptMessage := range kept {
+		c.Send <- keptMessage
+	}
+
+	return true
+}
+
+// isSupersededByLatest reports whether only the latest message of kind
+// matters to a client that is falling behind.
+func isSupersededByLatest(kind MessageKind) bool {
+	switch kind {
+	case NewBidPlaced, PriceDropped, AuctionExtended:
+		return true
+	default:
+		return false
+	}
+}
+
+// evict removes a client that cannot keep up from the room and has its
+// connection closed with a reason telling it to come back later.
+func (r *AuctionRoom) evict(c *Client) {
+	if r.Clients[c.UserID] != c {
+		return
+	}
+
+	slog.Warn("Evicting slow client", "RoomID", r.ID, "UserID", c.UserID)
+	metrics.EvictedClients.Inc()
+	r.removeClient(c)
+	close(c.evicted)
+}
+
+// publish hands a message to the room, giving up once the room has stopped.
+func (c *Client) publish(m Message) bool {
+	select {
+	case c.Room.Broadcast <- m:
+		return true
+	case <-c.Room.Done():
+		return false
+	}
+}
+
+func (c *Client) unregister() {
+	select {
+	case c.Room.Unregister <- c:
+	case <-c.Room.Done():
+	}
+}
+
 func (c *Client) ReadEventLoop() {
 	defer func() {
-		c.Room.Unregister <- c
+		c.unregister()
 		c.Conn.Close()
 	}()
 
-	c.Conn.SetReadLimit(maxMessageSize)
-	c.Conn.SetReadDeadline(time.Now().Add(readDeadline))
+	c.Conn.SetReadLimit(c.limits.MaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(c.limits.ReadDeadline))
 	c.Conn.SetPongHandler(func(string) error {
-		c.Conn.SetReadDeadline(time.Now().Add(readDeadline))
+		c.Conn.SetReadDeadline(time.Now().Add(c.limits.ReadDeadline))
 		return nil
 	})
 
 	for {
 		var m Message
-		m.UserID = c.UserID
 		err := c.Conn.ReadJSON(&m)
+		// Messages always act on behalf of the connected user, whatever
+		// user_id they carry.
+		m.UserID = c.UserID
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				slog.Error("Unexpected close error", "error", err)
+			var syntaxError *json.SyntaxError
+			var typeError *json.UnmarshalTypeError
+			if !errors.As(err, &syntaxError) && !errors.As(err, &typeError) {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+					slog.Error("Unexpected close error", "error", err)
+				}
 				return
 			}
 
-			c.Room.Broadcast <- Message{
+			if !c.publish(Message{
 				Kind:    InvalidJSON,
 				Message: "thus message should be valid JSON",
 				UserID:  m.UserID,
+			}) {
+				return
 			}
 			continue
 		}
 
-		c.Room.Broadcast <- m
+		if !c.publish(m) {
+			return
+		}
 	}
 }
 
 func (c *Client) WriteEventLoop() {
-	ticker := time.NewTicker(pingPeriod)
+	// Pings follow the room's clock; write deadlines stay on the wall clock
+	// the connection measures them against.
+	ticker := c.Room.clock.NewTicker(c.limits.pingPeriod())
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
@@ -202,12 +961,19 @@ func (c *Client) WriteEventLoop() {
 
 	for {
 		select {
-		case <-ticker.C:
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+		case <-ticker.C():
+			c.Conn.SetWriteDeadline(time.Now().Add(c.limits.WriteWait))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				slog.Error("Unexpected write error", "error", err)
 				return
 			}
+		case <-c.evicted:
+			c.Conn.SetWriteDeadline(time.Now().Add(c.limits.WriteWait))
+			c.Conn.WriteMessage(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client is too slow"),
+			)
+			return
 		case message, ok := <-c.Send:
 			if !ok {
 				c.Conn.WriteJSON(Message{
@@ -217,14 +983,24 @@ func (c *Client) WriteEventLoop() {
 				return
 			}
 
-			if message.Kind == AuctionFinished {
-				close(c.Send)
+			c.Conn.SetWriteDeadline(time.Now().Add(c.limits.WriteWait))
+			if err := c.Conn.WriteJSON(message); err != nil {
+				c.unregister()
 				return
 			}
 
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.Conn.WriteJSON(message); err != nil {
-				c.Room.Unregister <- c
+			switch message.Kind {
+			case AuctionFinished:
+				c.Conn.WriteMessage(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "auction has been finished"),
+				)
+				return
+			case ServerGoingAway:
+				c.Conn.WriteMessage(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server going away"),
+				)
 				return
 			}
 		}
@@ -0,0 +1,530 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/memstore"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func newTestRoom() *AuctionRoom {
+	return &AuctionRoom{
+		ID:      uuid.New(),
+		Clients: make(map[uuid.UUID]*Client),
+	}
+}
+
+func joinTestRoom(room *AuctionRoom, queueSize int, policy OverflowPolicy) *Client {
+	client := NewClient(room, nil, uuid.New(), ClientLimits{
+		SendQueueSize:  queueSize,
+		OverflowPolicy: policy,
+	})
+	room.Clients[client.UserID] = client
+
+	return client
+}
+
+func drainQueue(client *Client) []Message {
+	var messages []Message
+	for {
+		select {
+		case m := <-client.Send:
+			messages = append(messages, m)
+		default:
+			return messages
+		}
+	}
+}
+
+func isEvicted(client *Client) bool {
+	select {
+	case <-client.evicted:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestBroadcastDoesNotWaitForSlowClient(t *testing.T) {
+	const broadcasts = 500
+
+	room := newTestRoom()
+	fast := joinTestRoom(room, broadcasts, OverflowDisconnect)
+	slow := joinTestRoom(room, 8, OverflowDropOldest)
+
+	// The slow client reads a message every few milliseconds, far slower than
+	// the room broadcasts.
+	slowReceived := make(chan Message, broadcasts)
+	stopSlow := make(chan struct{})
+	defer close(stopSlow)
+	go func() {
+		for {
+			select {
+			case m := <-slow.Send:
+				slowReceived <- m
+				time.Sleep(5 * time.Millisecond)
+			case <-stopSlow:
+				return
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range broadcasts {
+			room.broadcast(Message{Kind: NewBidPlaced, Amount: int64(i + 1)}, nil)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("broadcasting stalled on the slow client")
+	}
+
+	received := drainQueue(fast)
+	if len(received) != broadcasts {
+		t.Fatalf("expected the fast client to receive %d messages, got %d", broadcasts, len(received))
+	}
+	for i, m := range received {
+		if m.Sequence != uint64(i+1) {
+			t.Fatalf("expected sequence %d, got %d", i+1, m.Sequence)
+		}
+	}
+
+	// The slow client eventually catches up with the latest broadcast.
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case m := <-slowReceived:
+			if m.Sequence == broadcasts {
+				if _, ok := room.Clients[slow.UserID]; !ok {
+					t.Fatal("expected the slow client to stay in the room")
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("the slow client never received the latest broadcast")
+		}
+	}
+}
+
+func TestCoalesceKeepsLatestPriceUpdate(t *testing.T) {
+	room := newTestRoom()
+	client := joinTestRoom(room, 4, OverflowCoalesce)
+
+	room.broadcast(Message{Kind: ReserveMet}, nil)
+	for i := range 10 {
+		room.broadcast(Message{Kind: NewBidPlaced, Amount: int64(i + 1)}, nil)
+	}
+	room.broadcast(Message{Kind: AuctionFinished}, nil)
+
+	if isEvicted(client) {
+		t.Fatal("expected the client to stay connected")
+	}
+
+	queued := drainQueue(client)
+	if len(queued) != 3 {
+		t.Fatalf("expected 3 queued messages, got %d: %+v", len(queued), queued)
+	}
+	if queued[0].Kind != ReserveMet {
+		t.Errorf("expected ReserveMet to be kept first, got kind %d", queued[0].Kind)
+	}
+	if queued[1].Kind != NewBidPlaced || queued[1].Amount != 10 {
+		t.Errorf("expected only the latest bid to be kept, got %+v", queued[1])
+	}
+	if queued[2].Kind != AuctionFinished {
+		t.Errorf("expected AuctionFinished last, got kind %d", queued[2].Kind)
+	}
+}
+
+func TestCoalesceKeepsPersonalizedMessages(t *testing.T) {
+	room := newTestRoom()
+	client := joinTestRoom(room, 3, OverflowCoalesce)
+
+	room.broadcast(Message{Kind: NewBidPlaced, Amount: 1}, func(id uuid.UUID, m Message) Message {
+		m.Message = "a bid was placed on your behalf"
+		m.UserID = id
+		return m
+	})
+	for i := range 5 {
+		room.broadcast(Message{Kind: NewBidPlaced, Amount: int64(i + 2)}, nil)
+	}
+
+	queued := drainQueue(client)
+	if len(queued) != 2 {
+		t.Fatalf("expected 2 queued messages, got %d: %+v", len(queued), queued)
+	}
+	if queued[0].UserID != client.UserID || queued[0].Amount != 1 {
+		t.Errorf("expected the bid placed on the client's behalf to be kept, got %+v", queued[0])
+	}
+	if queued[1].UserID != uuid.Nil || queued[1].Amount != 6 {
+		t.Errorf("expected only the latest of the other bids to be kept, got %+v", queued[1])
+	}
+}
+
+func TestCoalesceEvictsWhenNothingIsSuperseded(t *testing.T) {
+	room := newTestRoom()
+	client := joinTestRoom(room, 2, OverflowCoalesce)
+
+	for range 3 {
+		room.broadcast(Message{Kind: ReserveMet}, nil)
+	}
+
+	if !isEvicted(client) {
+		t.Fatal("expected the client to be evicted")
+	}
+	if _, ok := room.Clients[client.UserID]; ok {
+		t.Fatal("expected the client to leave the room")
+	}
+
+	// Unregistering once its loops stop must not evict it again.
+	room.unregisterClient(client)
+}
+
+func TestDisconnectPolicyEvictsOnFirstOverflow(t *testing.T) {
+	room := newTestRoom()
+	client := joinTestRoom(room, 1, OverflowDisconnect)
+	other := joinTestRoom(room, 1, OverflowDisconnect)
+
+	room.broadcast(Message{Kind: NewBidPlaced}, nil)
+	if isEvicted(client) {
+		t.Fatal("expected the client to fit its first message")
+	}
+	<-other.Send
+
+	room.broadcast(Message{Kind: NewBidPlaced}, nil)
+	if !isEvicted(client) {
+		t.Fatal("expected the client to be evicted")
+	}
+	if isEvicted(other) {
+		t.Fatal("expected the client keeping up to stay connected")
+	}
+}
+
+// startTestAuction runs a room for a product created by params on an
+// in-memory store that shares clk.
+func startTestAuction(t *testing.T, clk *clock.Fake, params pgstore.CreateProductParams) (*AuctionRoom, pgstore.Store) {
+	t.Helper()
+
+	ctx := context.Background()
+	store := memstore.NewWithClock(clk)
+	params.SellerID = createTestUser(t, store)
+	productId, err := store.CreateProduct(ctx, params)
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	product, err := store.GetProductById(ctx, productId)
+	if err != nil {
+		t.Fatalf("failed to get product: %v", err)
+	}
+
+	room := NewAuctionRoom(
+		ctx,
+		product,
+		NewBidsService(store, clk, SoftClose{Window: 2 * time.Minute, Extension: 2 * time.Minute}),
+		NewSettlementService(store, clk),
+		nil,
+		clk,
+	)
+	go room.Run()
+	t.Cleanup(func() {
+		room.Stop()
+		<-room.Done()
+	})
+
+	return room, store
+}
+
+// joinTestAuction registers a client for userId, which also waits for the
+// room to have armed its timers.
+func joinTestAuction(room *AuctionRoom, userId uuid.UUID) *Client {
+	client := NewClient(room, nil, userId, ClientLimits{})
+	room.Register <- client
+
+	return client
+}
+
+// expectMessages receives the next messages queued for client and checks
+// their kinds.
+func expectMessages(t *testing.T, client *Client, kinds ...MessageKind) []Message {
+	t.Helper()
+
+	messages := make([]Message, 0, len(kinds))
+	for _, kind := range kinds {
+		select {
+		case m := <-client.Send:
+			if m.Kind != kind {
+				t.Fatalf("expected message kind %d, got %+v", kind, m)
+			}
+			messages = append(messages, m)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for message kind %d", kind)
+		}
+	}
+
+	return messages
+}
+
+func TestAuctionRoomFinishesWhenClockReachesEnd(t *testing.T) {
+	clk := clock.NewFake(time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC))
+	auctionEnd := clk.Now().Add(3 * time.Hour)
+	room, store := startTestAuction(t, clk, pgstore.CreateProductParams{
+		ProductName:   "clockwork bird",
+		Description:   "a product auctioned on a fake clock",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionStart:  clk.Now(),
+		AuctionEnd:    auctionEnd,
+	})
+
+	alice := joinTestAuction(room, createTestUser(t, store))
+	bob := joinTestAuction(room, createTestUser(t, store))
+	expectMessages(t, alice, AuctionState)
+	expectMessages(t, bob, AuctionState)
+
+	room.Broadcast <- Message{Kind: PlaceBid, UserID: alice.UserID, Amount: 2000, Currency: "USD"}
+	expectMessages(t, alice, SuccessfullyPlacedBid, NewBidPlaced)
+	expectMessages(t, bob, NewBidPlaced)
+
+	// A bid a minute before the end falls inside the soft-close window.
+	clk.Advance(3*time.Hour - time.Minute)
+	room.Broadcast <- Message{Kind: PlaceBid, UserID: bob.UserID, Amount: 2100, Currency: "USD"}
+	expectMessages(t, bob, SuccessfullyPlacedBid, NewBidPlaced, AuctionExtended)
+	extended := expectMessages(t, alice, NewBidPlaced, AuctionExtended)[1]
+	if want := auctionEnd.Add(2 * time.Minute); !extended.AuctionEnd.Equal(want) {
+		t.Fatalf("expected the auction to be extended to %s, got %s", want, extended.AuctionEnd)
+	}
+
+	// The original end has passed, but the room keeps running.
+	clk.Advance(time.Minute)
+	carol := joinTestAuction(room, createTestUser(t, store))
+	expectMessages(t, carol, AuctionState)
+
+	clk.Advance(2 * time.Minute)
+	finished := expectMessages(t, alice, AuctionFinished)[0]
+	if finished.UserID != bob.UserID || finished.Amount != 2100 {
+		t.Fatalf("expected bob to win at 2100, got %+v", finished)
+	}
+	expectMessages(t, carol, AuctionFinished)
+
+	select {
+	case <-room.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the room to stop once the auction finished")
+	}
+
+	result, err := store.GetAuctionResultByProductId(context.Background(), room.ID)
+	if err != nil {
+		t.Fatalf("expected the auction to be settled: %v", err)
+	}
+	if !result.SettledAt.Equal(auctionEnd.Add(2 * time.Minute)) {
+		t.Errorf("expected the auction to be settled at the fake time, got %s", result.SettledAt)
+	}
+}
+
+func TestDutchAuctionRoomDropsPriceOnSchedule(t *testing.T) {
+	clk := clock.NewFake(time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC))
+	room, store := startTestAuction(t, clk, pgstore.CreateProductParams{
+		ProductName:   "melting clock",
+		Description:   "a dutch auction on a fake clock",
+		BasePrice:     10000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeDutch,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionStart:  clk.Now(),
+		AuctionEnd:    clk.Now().Add(3 * time.Hour),
+
+		PriceDropAmount:          pgtype.Int8{Int64: 1000, Valid: true},
+		PriceDropIntervalSeconds: pgtype.Int4{Int32: 60, Valid: true},
+		FloorPrice:               pgtype.Int8{Int64: 5000, Valid: true},
+	})
+
+	client := joinTestAuction(room, createTestUser(t, store))
+	if state := expectMessages(t, client, AuctionState)[0]; state.Amount != 10000 {
+		t.Fatalf("expected an asking price of 10000, got %d", state.Amount)
+	}
+
+	clk.Advance(time.Minute)
+	if dropped := expectMessages(t, client, PriceDropped)[0]; dropped.Amount != 9000 {
+		t.Fatalf("expected the price to drop to 9000, got %d", dropped.Amount)
+	}
+
+	// Waiting for the room to rearm its timer before each step makes the drops
+	// land on schedule rather than whenever the room catches up.
+	for _, want := range []int64{8000, 7000} {
+		clk.BlockUntil(2)
+		clk.Advance(time.Minute)
+		if dropped := expectMessages(t, client, PriceDropped)[0]; dropped.Amount != want {
+			t.Fatalf("expected the price to drop to %d, got %d", want, dropped.Amount)
+		}
+	}
+}
+
+func TestReplayRebuildsPersonalizedMessages(t *testing.T) {
+	clk := clock.NewFake(time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC))
+	room, store := startTestAuction(t, clk, pgstore.CreateProductParams{
+		ProductName:   "replayed proxy",
+		Description:   "an auction with a proxy bidder who reconnects",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionStart:  clk.Now(),
+		AuctionEnd:    clk.Now().Add(3 * time.Hour),
+	})
+
+	aliceId := createTestUser(t, store)
+	alice := joinTestAuction(room, aliceId)
+	bob := joinTestAuction(room, createTestUser(t, store))
+	expectMessages(t, alice, AuctionState)
+	expectMessages(t, bob, AuctionState)
+
+	room.Broadcast <- Message{Kind: SetMaxBid, UserID: aliceId, Amount: 5000, Currency: "USD"}
+	seen := expectMessages(t, alice, SuccessfullySetMaxBid, NewBidPlaced)[1]
+	expectMessages(t, bob, NewBidPlaced)
+
+	// Alice misses bob's bids and the proxy bid answering the first one. The
+	// notice that the second one exceeded her maximum was only meant for her
+	// live connection and is not replayed.
+	room.Unregister <- alice
+	room.Broadcast <- Message{Kind: PlaceBid, UserID: bob.UserID, Amount: 2000, Currency: "USD"}
+	expectMessages(t, bob, SuccessfullyPlacedBid, NewBidPlaced, NewBidPlaced)
+	room.Broadcast <- Message{Kind: PlaceBid, UserID: bob.UserID, Amount: 6000, Currency: "USD"}
+	expectMessages(t, bob, SuccessfullyPlacedBid, NewBidPlaced)
+
+	alice = NewClient(room, nil, aliceId, ClientLimits{})
+	alice.LastSequence = seen.Sequence
+	room.Register <- alice
+	replayed := expectMessages(t, alice, NewBidPlaced, NewBidPlaced, NewBidPlaced, AuctionState)
+	if replayed[0].UserID != uuid.Nil || replayed[0].Amount != 2000 {
+		t.Errorf("expected bob's bid to be replayed as is, got %+v", replayed[0])
+	}
+	if proxy := replayed[1]; proxy.UserID != aliceId || proxy.Message != "a bid was placed on your behalf" || proxy.Amount != 2100 {
+		t.Errorf("expected the proxy bid to be replayed as placed on alice's behalf, got %+v", proxy)
+	}
+	if own := replayed[2]; own.UserID != uuid.Nil || own.Amount != 6000 {
+		t.Errorf("expected bob's second bid to be replayed as is, got %+v", own)
+	}
+}
+
+// receiveUntilFinished receives the messages queued for client up to and
+// including AuctionFinished.
+func receiveUntilFinished(t *testing.T, client *Client) []Message {
+	t.Helper()
+
+	var messages []Message
+	for {
+		select {
+		case m := <-client.Send:
+			messages = append(messages, m)
+			if m.Kind == AuctionFinished {
+				return messages
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for the auction to finish, got %+v", messages)
+		}
+	}
+}
+
+func TestSequenceIsContiguousForEveryClientAcrossProxyWar(t *testing.T) {
+	clk := clock.NewFake(time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC))
+	room, store := startTestAuction(t, clk, pgstore.CreateProductParams{
+		ProductName:   "contested clock",
+		Description:   "an auction fought between proxy bidders",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionStart:  clk.Now(),
+		AuctionEnd:    clk.Now().Add(3 * time.Hour),
+	})
+
+	alice := joinTestAuction(room, createTestUser(t, store))
+	bob := joinTestAuction(room, createTestUser(t, store))
+	carol := joinTestAuction(room, createTestUser(t, store))
+	clients := []*Client{alice, bob, carol}
+
+	// Every bid is placed on behalf of one client, and bob's maximum and then
+	// alice's are exceeded.
+	room.Broadcast <- Message{Kind: SetMaxBid, UserID: alice.UserID, Amount: 3000, Currency: "USD"}
+	room.Broadcast <- Message{Kind: SetMaxBid, UserID: bob.UserID, Amount: 2500, Currency: "USD"}
+	room.Broadcast <- Message{Kind: PlaceBid, UserID: bob.UserID, Amount: 2700, Currency: "USD"}
+	room.Broadcast <- Message{Kind: PlaceBid, UserID: carol.UserID, Amount: 4000, Currency: "USD"}
+	clk.Advance(3 * time.Hour)
+
+	for _, client := range clients {
+		messages := receiveUntilFinished(t, client)
+		if messages[0].Kind != AuctionState {
+			t.Fatalf("expected the auction state first, got %+v", messages[0])
+		}
+
+		want := messages[0].Sequence + 1
+		exceeded := 0
+		for _, m := range messages[1:] {
+			switch m.Kind {
+			case SuccessfullySetMaxBid, SuccessfullyPlacedBid, MaxBidExceeded:
+				if m.Sequence != 0 {
+					t.Errorf("expected %+v, sent to one client, to have no sequence", m)
+				}
+				if m.Kind == MaxBidExceeded {
+					exceeded++
+				}
+			default:
+				if m.Sequence != want {
+					t.Fatalf("expected sequence %d, got %+v", want, m)
+				}
+				want++
+			}
+		}
+		if client != carol && exceeded == 0 {
+			t.Errorf("expected %s to be told their max bid was exceeded", client.UserID)
+		}
+	}
+}
+
+func TestDeliverDoesNotWaitForRoom(t *testing.T) {
+	clk := clock.NewFake(time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC))
+	product := pgstore.Product{ID: uuid.New(), AuctionType: pgstore.AuctionTypeDutch, BasePrice: 10000}
+	room := NewAuctionRoom(context.Background(), product, BidsService{}, SettlementService{}, nil, clk)
+
+	// The room is not running, as if it were busy.
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		for i := range 100 {
+			room.Deliver(AuctionEvent{Kind: AuctionEventPriceDropped, Price: int64(9900 - i)})
+		}
+	}()
+
+	select {
+	case <-delivered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("delivering events stalled on a room that is not running")
+	}
+
+	client := joinTestRoom(room, 100, OverflowDisconnect)
+	<-room.eventsReady
+	for _, event := range room.takeEvents() {
+		room.handleEvent(event)
+	}
+
+	queued := drainQueue(client)
+	if len(queued) != 100 {
+		t.Fatalf("expected 100 price drops, got %d", len(queued))
+	}
+	for i, m := range queued {
+		if m.Kind != PriceDropped || m.Amount != int64(9900-i) {
+			t.Fatalf("expected price drop %d to %d, got %+v", i, 9900-i, m)
+		}
+	}
+}
@@ -3,57 +3,689 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
 	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type BidsService struct {
-	pool    *pgxpool.Pool
-	queries *pgstore.Queries
+	store     pgstore.Store
+	clock     clock.Clock
+	softClose SoftClose
 }
 
-func NewBidsService(pool *pgxpool.Pool) BidsService {
+// SoftClose configures anti-sniping: a bid placed less than Window before the
+// end of an auction pushes the end back by Extension. A zero Window disables it.
+type SoftClose struct {
+	Window    time.Duration
+	Extension time.Duration
+}
+
+// PlacedBid describes everything a bid or a new maximum caused: the bids that
+// were stored (the bidder's own bid first, then automatic proxy bids), the
+// bidders whose maximum was exceeded, whether the reserve price has just been
+// reached and the auction end it left behind.
+type PlacedBid struct {
+	Bids       []pgstore.Bid
+	Exceeded   []uuid.UUID
+	ReserveMet bool
+	AuctionEnd time.Time
+	Extended   bool
+}
+
+func NewBidsService(store pgstore.Store, clk clock.Clock, softClose SoftClose) BidsService {
 	return BidsService{
-		pool:    pool,
-		queries: pgstore.New(pool),
+		store:     store,
+		clock:     clk,
+		softClose: softClose,
+	}
+}
+
+var (
+	ErrBidIsTooLow          = errors.New("bid is too low")
+	ErrBidIncrementTooSmall = errors.New("bid increment is too small")
+	ErrAuctionEnded         = errors.New("auction has ended")
+	ErrMaxBidIsTooLow       = errors.New("max bid must be above the current price")
+	ErrBuyNowUnavailable    = errors.New("buy it now is not available")
+	ErrUnsupportedByAuction = errors.New("not supported by this auction type")
+	ErrAuctionNotStarted    = errors.New("auction has not started yet")
+	ErrNotProductSeller     = errors.New("only the seller can see bidder user names")
+)
+
+// IsSealedAuction reports whether bids in auctions of type t are kept secret
+// until the auction is settled.
+func IsSealedAuction(t pgstore.AuctionType) bool {
+	return t == pgstore.AuctionTypeSealedFirstPrice || t == pgstore.AuctionTypeSealedSecondPrice
+}
+
+// DutchPrice is the asking price of a dutch auction at t: the base price
+// lowered by the drop amount once every drop interval since the auction
+// started, never below the floor price.
+func DutchPrice(product pgstore.Product, t time.Time) int64 {
+	drop := product.PriceDropAmount.Int64
+	interval := time.Duration(product.PriceDropIntervalSeconds.Int32) * time.Second
+	if drop <= 0 || interval <= 0 || !t.After(product.AuctionStart) {
+		return product.BasePrice
+	}
+
+	floor := product.FloorPrice.Int64
+	drops := min(int64(t.Sub(product.AuctionStart)/interval), (product.BasePrice-floor+drop-1)/drop)
+
+	return max(product.BasePrice-drops*drop, floor)
+}
+
+// NextDutchPriceDrop returns when the asking price of a dutch auction drops
+// next after t, and false once it has reached its floor.
+func NextDutchPriceDrop(product pgstore.Product, t time.Time) (time.Time, bool) {
+	interval := time.Duration(product.PriceDropIntervalSeconds.Int32) * time.Second
+	if interval <= 0 {
+		return time.Time{}, false
+	}
+
+	elapsed := max(t.Sub(product.AuctionStart), 0)
+	next := product.AuctionStart.Add((elapsed/interval + 1) * interval)
+	if DutchPrice(product, next) == DutchPrice(product, t) {
+		return time.Time{}, false
 	}
+
+	return next, true
+}
+
+// MinimumBidError rejects a bid or max bid and carries the lowest amount the
+// product would currently accept.
+type MinimumBidError struct {
+	Err     error
+	Minimum money.Money
 }
 
-var ErrBidIsTooLow = errors.New("bid is too low")
+func (e *MinimumBidError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MinimumBidError) Unwrap() error {
+	return e.Err
+}
 
-func (bs *BidsService) PlaceBid(ctx context.Context, product_id, bidder_id uuid.UUID, amount float64) (pgstore.Bid, error) {
-	product, err := bs.queries.GetProductById(ctx, product_id)
+// PlaceBid accepts a bid atomically: the product row stays locked until the
+// bid is stored, so concurrent bids for the same product are validated one at
+// a time against the current highest bid. Bids are always expressed in the
+// product's currency; an empty currency is taken as the product's. A bid inside
+// the soft-close window extends the auction in the same transaction. Sealed
+// auctions take one bid per bidder instead, and a new bid replaces the old one.
+func (bs *BidsService) PlaceBid(ctx context.Context, product_id, bidder_id uuid.UUID, amount money.Money) (PlacedBid, error) {
+	qtx, err := bs.store.Begin(ctx)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return pgstore.Bid{}, err
-		}
+		return PlacedBid{}, err
+	}
+	defer qtx.Rollback(ctx)
+
+	product, now, err := bs.lockOpenAuction(ctx, qtx, product_id, amount.Currency)
+	if err != nil {
+		return PlacedBid{}, err
+	}
+
+	if product.AuctionType == pgstore.AuctionTypeDutch {
+		return PlacedBid{}, ErrUnsupportedByAuction
+	}
+
+	var placed PlacedBid
+	if IsSealedAuction(product.AuctionType) {
+		placed, err = bs.placeSealedBid(ctx, qtx, product, bidder_id, amount)
+	} else {
+		placed, err = bs.placeOpenBid(ctx, qtx, product, now, bidder_id, amount)
+	}
+	if err != nil {
+		return PlacedBid{}, err
 	}
 
-	highestBid, err := bs.queries.GetHighestBidByProductId(ctx, product_id)
+	if err := qtx.Commit(ctx); err != nil {
+		return PlacedBid{}, err
+	}
+
+	return placed, nil
+}
+
+func (bs *BidsService) placeOpenBid(
+	ctx context.Context,
+	qtx pgstore.Querier,
+	product pgstore.Product,
+	now time.Time,
+	bidder_id uuid.UUID,
+	amount money.Money,
+) (PlacedBid, error) {
+	highestBid, err := qtx.GetHighestBidByProductId(ctx, product.ID)
 	if err != nil {
 		if !errors.Is(err, pgx.ErrNoRows) {
-			return pgstore.Bid{}, err
+			return PlacedBid{}, err
 		}
 	}
 
-	if product.BasePrice >= amount || highestBid.BidAmount >= amount {
-		return pgstore.Bid{}, ErrBidIsTooLow
+	currentPrice := max(product.BasePrice, highestBid.BidAmount)
+	minimum := product.BidIncrements.NextMinimum(currentPrice)
+	if amount.Amount < minimum {
+		err := ErrBidIsTooLow
+		if amount.Amount > currentPrice {
+			err = ErrBidIncrementTooSmall
+		}
+
+		return PlacedBid{}, &MinimumBidError{
+			Err:     err,
+			Minimum: money.Money{Amount: minimum, Currency: product.Currency},
+		}
 	}
 
-	highestBid, err = bs.queries.CreateBid(
+	bid, err := qtx.CreateBid(
 		ctx,
 		pgstore.CreateBidParams{
-			ProductID: product_id,
+			ProductID: product.ID,
 			BidderID:  bidder_id,
-			BidAmount: amount,
+			BidAmount: amount.Amount,
 		},
 	)
 	if err != nil {
-		return pgstore.Bid{}, err
+		return PlacedBid{}, err
+	}
+
+	placed := PlacedBid{Bids: []pgstore.Bid{bid}}
+	if err := bs.resolveProxyBids(ctx, qtx, product, highestBid, &placed); err != nil {
+		return PlacedBid{}, err
+	}
+
+	if err := bs.extendAuction(ctx, qtx, product, now, &placed); err != nil {
+		return PlacedBid{}, err
+	}
+
+	return placed, nil
+}
+
+// placeSealedBid stores the bidder's only bid, replacing a previous one. Sealed
+// bids only need to reach the base price since nobody sees the others.
+func (bs *BidsService) placeSealedBid(
+	ctx context.Context,
+	qtx pgstore.Querier,
+	product pgstore.Product,
+	bidder_id uuid.UUID,
+	amount money.Money,
+) (PlacedBid, error) {
+	if amount.Amount < product.BasePrice {
+		return PlacedBid{}, &MinimumBidError{
+			Err:     ErrBidIsTooLow,
+			Minimum: money.Money{Amount: product.BasePrice, Currency: product.Currency},
+		}
+	}
+
+	err := qtx.DeleteBidsByProductIdAndBidderId(ctx, pgstore.DeleteBidsByProductIdAndBidderIdParams{
+		ProductID: product.ID,
+		BidderID:  bidder_id,
+	})
+	if err != nil {
+		return PlacedBid{}, err
+	}
+
+	bid, err := qtx.CreateBid(
+		ctx,
+		pgstore.CreateBidParams{
+			ProductID: product.ID,
+			BidderID:  bidder_id,
+			BidAmount: amount.Amount,
+		},
+	)
+	if err != nil {
+		return PlacedBid{}, err
+	}
+
+	return PlacedBid{Bids: []pgstore.Bid{bid}, AuctionEnd: product.AuctionEnd}, nil
+}
+
+// SetMaxBid registers (or replaces) the secret maximum a bidder is willing to
+// pay. The server then bids on their behalf whenever they are outbid, which
+// may immediately produce proxy bids.
+func (bs *BidsService) SetMaxBid(ctx context.Context, product_id, bidder_id uuid.UUID, maxAmount money.Money) (PlacedBid, error) {
+	qtx, err := bs.store.Begin(ctx)
+	if err != nil {
+		return PlacedBid{}, err
+	}
+	defer qtx.Rollback(ctx)
+
+	product, now, err := bs.lockOpenAuction(ctx, qtx, product_id, maxAmount.Currency)
+	if err != nil {
+		return PlacedBid{}, err
+	}
+
+	if product.AuctionType != pgstore.AuctionTypeEnglish {
+		return PlacedBid{}, ErrUnsupportedByAuction
+	}
+
+	highestBid, err := qtx.GetHighestBidByProductId(ctx, product_id)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return PlacedBid{}, err
+		}
+	}
+
+	minimum := product.BidIncrements.NextMinimum(max(product.BasePrice, highestBid.BidAmount))
+	if maxAmount.Amount < minimum {
+		return PlacedBid{}, &MinimumBidError{
+			Err:     ErrMaxBidIsTooLow,
+			Minimum: money.Money{Amount: minimum, Currency: product.Currency},
+		}
+	}
+
+	_, err = qtx.UpsertMaxBid(ctx, pgstore.UpsertMaxBidParams{
+		ProductID: product_id,
+		BidderID:  bidder_id,
+		MaxAmount: maxAmount.Amount,
+	})
+	if err != nil {
+		return PlacedBid{}, err
+	}
+
+	var placed PlacedBid
+	if err := bs.resolveProxyBids(ctx, qtx, product, highestBid, &placed); err != nil {
+		return PlacedBid{}, err
+	}
+
+	if len(placed.Bids) > 0 {
+		if err := bs.extendAuction(ctx, qtx, product, now, &placed); err != nil {
+			return PlacedBid{}, err
+		}
+	} else {
+		placed.AuctionEnd = product.AuctionEnd
+	}
+
+	if err := qtx.Commit(ctx); err != nil {
+		return PlacedBid{}, err
+	}
+
+	return placed, nil
+}
+
+// BuyNow sells the product to buyer_id at its buy-now price, which is only
+// offered while there are no bids or, when the product has a reserve price,
+// while no bid has met it. The bid, the sale and the auction result are
+// written in a single transaction, ending the auction immediately.
+func (bs *BidsService) BuyNow(ctx context.Context, product_id, buyer_id uuid.UUID) (pgstore.AuctionResult, error) {
+	qtx, err := bs.store.Begin(ctx)
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+	defer qtx.Rollback(ctx)
+
+	product, now, err := bs.lockOpenAuction(ctx, qtx, product_id, "")
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	if product.AuctionType != pgstore.AuctionTypeEnglish {
+		return pgstore.AuctionResult{}, ErrUnsupportedByAuction
+	}
+
+	if !product.BuyNowPrice.Valid {
+		return pgstore.AuctionResult{}, ErrBuyNowUnavailable
+	}
+
+	highestBid, err := qtx.GetHighestBidByProductId(ctx, product_id)
+	if err == nil {
+		reserveMet := !product.ReservePrice.Valid || highestBid.BidAmount >= product.ReservePrice.Int64
+		if reserveMet {
+			return pgstore.AuctionResult{}, ErrBuyNowUnavailable
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return pgstore.AuctionResult{}, err
+	}
+
+	result, err := bs.sell(ctx, qtx, product, now, buyer_id, product.BuyNowPrice.Int64, AuctionResultBoughtNow)
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	if err := qtx.Commit(ctx); err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	return result, nil
+}
+
+// AcceptPrice sells the product of a dutch auction to buyer_id at the asking
+// price computed at the time of the request, ending the auction immediately.
+func (bs *BidsService) AcceptPrice(ctx context.Context, product_id, buyer_id uuid.UUID) (pgstore.AuctionResult, error) {
+	qtx, err := bs.store.Begin(ctx)
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+	defer qtx.Rollback(ctx)
+
+	product, now, err := bs.lockOpenAuction(ctx, qtx, product_id, "")
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	if product.AuctionType != pgstore.AuctionTypeDutch {
+		return pgstore.AuctionResult{}, ErrUnsupportedByAuction
+	}
+
+	result, err := bs.sell(ctx, qtx, product, now, buyer_id, DutchPrice(product, now), AuctionResultSold)
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	if err := qtx.Commit(ctx); err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	return result, nil
+}
+
+// sell records a bid of price by buyer_id, ends the auction at now and settles
+// it in favour of that bid.
+func (bs *BidsService) sell(
+	ctx context.Context,
+	qtx pgstore.Querier,
+	product pgstore.Product,
+	now time.Time,
+	buyer_id uuid.UUID,
+	price int64,
+	status string,
+) (pgstore.AuctionResult, error) {
+	bid, err := qtx.CreateBid(ctx, pgstore.CreateBidParams{
+		ProductID: product.ID,
+		BidderID:  buyer_id,
+		BidAmount: price,
+	})
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	err = qtx.UpdateProductAuctionEnd(ctx, pgstore.UpdateProductAuctionEndParams{
+		ID:         product.ID,
+		AuctionEnd: now,
+	})
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	if err := qtx.MarkProductAsSold(ctx, product.ID); err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	return qtx.CreateAuctionResult(ctx, pgstore.CreateAuctionResultParams{
+		ProductID:    product.ID,
+		WinnerID:     pgtype.UUID{Bytes: buyer_id, Valid: true},
+		WinningBidID: pgtype.UUID{Bytes: bid.ID, Valid: true},
+		FinalPrice:   pgtype.Int8{Int64: price, Valid: true},
+		Currency:     product.Currency,
+		Status:       status,
+	})
+}
+
+// GetBidStats returns the highest bid of a product (zero without bids) and
+// how many bids it has received.
+func (bs *BidsService) GetBidStats(ctx context.Context, product_id uuid.UUID) (int64, int64, error) {
+	stats, err := bs.store.GetBidStatsByProductId(ctx, product_id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return stats.HighBid, stats.BidCount, nil
+}
+
+// BidHistoryEntry is a bid as shown in an auction's history. Bidders are
+// labelled by the order of their first bid, which is stable for the whole
+// auction; UserName is only filled in for the seller.
+type BidHistoryEntry struct {
+	ID        uuid.UUID `json:"id"`
+	Bidder    string    `json:"bidder"`
+	UserName  string    `json:"user_name,omitempty"`
+	Amount    *int64    `json:"amount,omitempty"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+	IsOwn     bool      `json:"is_own"`
+}
+
+// BidHistoryQuery pages through a bid history for Viewer, starting from the
+// newest bid. ShowUserNames is only allowed for the product's seller.
+type BidHistoryQuery struct {
+	Viewer        uuid.UUID
+	ShowUserNames bool
+	Cursor        string
+	Limit         int32
+}
+
+// BidHistoryPage is a page of bids; NextCursor is empty on the last page.
+type BidHistoryPage struct {
+	Bids       []BidHistoryEntry `json:"bids"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type bidHistoryCursor struct {
+	Seq int64 `json:"seq"`
+}
+
+// ListBidHistory returns the bids of a product from the newest. Amounts of a
+// sealed auction are only shown to their own bidder until the auction ends.
+func (bs *BidsService) ListBidHistory(ctx context.Context, product_id uuid.UUID, query BidHistoryQuery) (BidHistoryPage, error) {
+	product, err := bs.store.GetProductById(ctx, product_id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return BidHistoryPage{}, ErrProductNotFound
+		}
+
+		return BidHistoryPage{}, err
+	}
+
+	if query.ShowUserNames && query.Viewer != product.SellerID {
+		return BidHistoryPage{}, ErrNotProductSeller
+	}
+
+	args := pgstore.ListBidHistoryByProductIdParams{
+		ProductID: product_id,
+		PageSize:  query.Limit + 1,
+	}
+	if query.Cursor != "" {
+		var cursor bidHistoryCursor
+		if err := decodeCursor(query.Cursor, &cursor); err != nil {
+			return BidHistoryPage{}, err
+		}
+		if cursor.Seq <= 0 {
+			return BidHistoryPage{}, ErrInvalidCursor
+		}
+
+		args.BeforeSeq = pgtype.Int8{Int64: cursor.Seq, Valid: true}
+	}
+
+	rows, err := bs.store.ListBidHistoryByProductId(ctx, args)
+	if err != nil {
+		return BidHistoryPage{}, err
 	}
 
-	return highestBid, nil
+	page := BidHistoryPage{Bids: make([]BidHistoryEntry, 0, len(rows))}
+	if len(rows) > int(query.Limit) {
+		rows = rows[:query.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(bidHistoryCursor{Seq: last.Seq})
+	}
+
+	hideAmounts := IsSealedAuction(product.AuctionType) && bs.clock.Now().Before(product.AuctionEnd)
+	for _, row := range rows {
+		entry := BidHistoryEntry{
+			ID:        row.ID,
+			Bidder:    fmt.Sprintf("Bidder %d", row.BidderNumber),
+			Currency:  product.Currency,
+			CreatedAt: row.CreatedAt,
+			IsOwn:     row.BidderID == query.Viewer,
+		}
+		if !hideAmounts || entry.IsOwn {
+			entry.Amount = &row.BidAmount
+		}
+		if query.ShowUserNames {
+			entry.UserName = row.UserName
+		}
+
+		page.Bids = append(page.Bids, entry)
+	}
+
+	return page, nil
+}
+
+// lockOpenAuction locks the product row for the rest of the transaction and
+// checks that it still accepts bids in the given currency.
+func (bs *BidsService) lockOpenAuction(
+	ctx context.Context,
+	qtx pgstore.Querier,
+	product_id uuid.UUID,
+	currency string,
+) (pgstore.Product, time.Time, error) {
+	product, err := qtx.GetProductByIdForUpdate(ctx, product_id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgstore.Product{}, time.Time{}, ErrProductNotFound
+		}
+
+		return pgstore.Product{}, time.Time{}, err
+	}
+
+	now := bs.clock.Now()
+	if product.IsSold || !now.Before(product.AuctionEnd) {
+		return pgstore.Product{}, time.Time{}, ErrAuctionEnded
+	}
+
+	if now.Before(product.AuctionStart) {
+		return pgstore.Product{}, time.Time{}, ErrAuctionNotStarted
+	}
+
+	if currency != "" && currency != product.Currency {
+		return pgstore.Product{}, time.Time{}, money.ErrCurrencyMismatch
+	}
+
+	return product, now, nil
+}
+
+// resolveProxyBids lets registered maximums answer the current highest bid.
+// The proxy with the highest maximum (the earliest one on ties) ends up
+// winning at one increment above its strongest competitor, capped at its own
+// maximum; the runner-up proxy is recorded bidding its full maximum. Proxies
+// that cannot reach the product's next minimum bid stay out of the war.
+// previousBid is the highest bid before the action that triggered the
+// resolution and is used to find the bidders whose maximum was just exceeded.
+func (bs *BidsService) resolveProxyBids(
+	ctx context.Context,
+	qtx pgstore.Querier,
+	product pgstore.Product,
+	previousBid pgstore.Bid,
+	placed *PlacedBid,
+) error {
+	highestBid, err := qtx.GetHighestBidByProductId(ctx, product.ID)
+	hasHighestBid := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	maxBids, err := qtx.ListMaxBidsByProductId(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+
+	currentPrice := product.BasePrice
+	if hasHighestBid {
+		currentPrice = highestBid.BidAmount
+	}
+
+	nextMinimum := product.BidIncrements.NextMinimum(currentPrice)
+
+	var active []pgstore.MaxBid
+	for _, maxBid := range maxBids {
+		isWinning := hasHighestBid && highestBid.BidderID == maxBid.BidderID
+		if isWinning || maxBid.MaxAmount >= nextMinimum {
+			active = append(active, maxBid)
+		}
+	}
+
+	if len(active) > 0 {
+		top := active[0]
+		topIsWinning := hasHighestBid && highestBid.BidderID == top.BidderID
+
+		var proxyBids []pgstore.CreateBidParams
+		if len(active) > 1 && active[1].MaxAmount >= nextMinimum {
+			runnerUp := active[1]
+			price := min(top.MaxAmount, product.BidIncrements.NextMinimum(runnerUp.MaxAmount))
+			if runnerUp.MaxAmount < price {
+				proxyBids = append(proxyBids, pgstore.CreateBidParams{
+					ProductID: product.ID,
+					BidderID:  runnerUp.BidderID,
+					BidAmount: runnerUp.MaxAmount,
+				})
+			}
+			proxyBids = append(proxyBids, pgstore.CreateBidParams{
+				ProductID: product.ID,
+				BidderID:  top.BidderID,
+				BidAmount: price,
+			})
+		} else if !topIsWinning {
+			proxyBids = append(proxyBids, pgstore.CreateBidParams{
+				ProductID: product.ID,
+				BidderID:  top.BidderID,
+				BidAmount: min(top.MaxAmount, nextMinimum),
+			})
+		}
+
+		for _, args := range proxyBids {
+			bid, err := qtx.CreateBid(ctx, args)
+			if err != nil {
+				return err
+			}
+
+			placed.Bids = append(placed.Bids, bid)
+			highestBid = bid
+			hasHighestBid = true
+		}
+	}
+
+	if !hasHighestBid {
+		return nil
+	}
+
+	if product.ReservePrice.Valid {
+		reserve := product.ReservePrice.Int64
+		placed.ReserveMet = previousBid.BidAmount < reserve && highestBid.BidAmount >= reserve
+	}
+
+	previousPrice := max(product.BasePrice, previousBid.BidAmount)
+	for _, maxBid := range maxBids {
+		if maxBid.BidderID == highestBid.BidderID || maxBid.MaxAmount > highestBid.BidAmount {
+			continue
+		}
+		if maxBid.MaxAmount > previousPrice || maxBid.BidderID == previousBid.BidderID {
+			placed.Exceeded = append(placed.Exceeded, maxBid.BidderID)
+		}
+	}
+
+	return nil
+}
+
+// extendAuction applies the soft-close rule to a bid placed at now.
+func (bs *BidsService) extendAuction(
+	ctx context.Context,
+	qtx pgstore.Querier,
+	product pgstore.Product,
+	now time.Time,
+	placed *PlacedBid,
+) error {
+	placed.AuctionEnd = product.AuctionEnd
+	if bs.softClose.Window <= 0 || product.AuctionEnd.Sub(now) > bs.softClose.Window {
+		return nil
+	}
+
+	placed.AuctionEnd = product.AuctionEnd.Add(bs.softClose.Extension)
+	placed.Extended = true
+
+	return qtx.UpdateProductAuctionEnd(ctx, pgstore.UpdateProductAuctionEndParams{
+		ID:         product.ID,
+		AuctionEnd: placed.AuctionEnd,
+	})
 }
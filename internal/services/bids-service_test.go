@@ -0,0 +1,766 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/memstore"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestPool connects to the database described by the GOBID_DATABASE_*
+// variables. Tests that need Postgres are skipped when it is not configured.
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	if os.Getenv("GOBID_DATABASE_HOST") == "" {
+		t.Skip("GOBID_DATABASE_HOST is not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), fmt.Sprintf(
+		"user=%s password=%s host=%s port=%s dbname=%s",
+		os.Getenv("GOBID_DATABASE_USER"),
+		os.Getenv("GOBID_DATABASE_PASSWORD"),
+		os.Getenv("GOBID_DATABASE_HOST"),
+		os.Getenv("GOBID_DATABASE_PORT"),
+		os.Getenv("GOBID_DATABASE_NAME"),
+	))
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if err := pool.Ping(context.Background()); err != nil {
+		t.Fatalf("failed to ping database: %v", err)
+	}
+
+	return pool
+}
+
+// forEachStore runs test against the in-memory store and, when Postgres is
+// configured, against Postgres too.
+func forEachStore(t *testing.T, test func(t *testing.T, store pgstore.Store)) {
+	t.Run("memstore", func(t *testing.T) {
+		test(t, memstore.New())
+	})
+	t.Run("pgstore", func(t *testing.T) {
+		test(t, pgstore.NewStore(newTestPool(t)))
+	})
+}
+
+func createTestUser(t *testing.T, queries pgstore.Querier) uuid.UUID {
+	t.Helper()
+
+	name := uuid.NewString()
+	id, err := queries.CreateUser(context.Background(), pgstore.CreateUserParams{
+		UserName:     name,
+		Email:        name + "@gobid.test",
+		PasswordHash: []byte("hash"),
+		Bio:          "a user created by tests",
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	return id
+}
+
+// endTestAuction moves the end of an auction to now so it can be settled.
+func endTestAuction(t *testing.T, queries pgstore.Querier, productId uuid.UUID) {
+	t.Helper()
+
+	err := queries.UpdateProductAuctionEnd(context.Background(), pgstore.UpdateProductAuctionEndParams{
+		ID:         productId,
+		AuctionEnd: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("failed to end auction: %v", err)
+	}
+}
+
+func TestPlaceBidConcurrently(t *testing.T) {
+	forEachStore(t, testPlaceBidConcurrently)
+}
+
+func testPlaceBidConcurrently(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	sellerId := createTestUser(t, store)
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      sellerId,
+		ProductName:   "concurrency test",
+		Description:   "a product hammered by concurrent bids",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionEnd:    time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	const bidders = 20
+	const rounds = 5
+
+	bidderIds := make([]uuid.UUID, bidders)
+	for i := range bidderIds {
+		bidderIds[i] = createTestUser(t, store)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+
+	for round := 1; round <= rounds; round++ {
+		amount := money.Money{Amount: int64(1000 + round*100), Currency: "USD"}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		accepted := 0
+
+		for _, bidderId := range bidderIds {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := bs.PlaceBid(ctx, productId, bidderId, amount)
+				if err != nil {
+					if !errors.Is(err, ErrBidIsTooLow) {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		if accepted != 1 {
+			t.Fatalf("round %d: expected exactly one accepted bid of %d %s, got %d", round, amount.Amount, amount.Currency, accepted)
+		}
+	}
+
+	bids, err := store.GetBidsByProductId(ctx, productId)
+	if err != nil {
+		t.Fatalf("failed to list bids: %v", err)
+	}
+	if len(bids) != rounds {
+		t.Fatalf("expected %d bids, got %d", rounds, len(bids))
+	}
+}
+
+func TestPlaceBidAfterAuctionEnd(t *testing.T) {
+	forEachStore(t, testPlaceBidAfterAuctionEnd)
+}
+
+func testPlaceBidAfterAuctionEnd(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	sellerId := createTestUser(t, store)
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      sellerId,
+		ProductName:   "ended auction",
+		Description:   "a product whose auction already ended",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionEnd:    time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+	if _, err := bs.PlaceBid(ctx, productId, createTestUser(t, store), money.Money{Amount: 2000, Currency: "USD"}); !errors.Is(err, ErrAuctionEnded) {
+		t.Fatalf("expected ErrAuctionEnded, got %v", err)
+	}
+}
+
+func TestPlaceBidInsideSoftCloseWindowExtendsAuction(t *testing.T) {
+	forEachStore(t, testPlaceBidInsideSoftCloseWindowExtendsAuction)
+}
+
+func testPlaceBidInsideSoftCloseWindowExtendsAuction(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	auctionEnd := time.Now().Add(time.Minute).Truncate(time.Microsecond)
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      createTestUser(t, store),
+		ProductName:   "soft close",
+		Description:   "a product receiving a last minute bid",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionEnd:    auctionEnd,
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{Window: 2 * time.Minute, Extension: 2 * time.Minute})
+	placed, err := bs.PlaceBid(ctx, productId, createTestUser(t, store), money.Money{Amount: 2000, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("failed to place bid: %v", err)
+	}
+
+	expectedEnd := auctionEnd.Add(2 * time.Minute)
+	if !placed.Extended || !placed.AuctionEnd.Equal(expectedEnd) {
+		t.Fatalf("expected auction to be extended to %v, got %v (extended: %v)", expectedEnd, placed.AuctionEnd, placed.Extended)
+	}
+
+	product, err := store.GetProductById(ctx, productId)
+	if err != nil {
+		t.Fatalf("failed to get product: %v", err)
+	}
+	if !product.AuctionEnd.Equal(expectedEnd) {
+		t.Fatalf("expected stored auction end %v, got %v", expectedEnd, product.AuctionEnd)
+	}
+}
+
+func TestSetMaxBidResolvesProxyWar(t *testing.T) {
+	forEachStore(t, testSetMaxBidResolvesProxyWar)
+}
+
+func testSetMaxBidResolvesProxyWar(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      createTestUser(t, store),
+		ProductName:   "proxy war",
+		Description:   "a product disputed by two proxy bidders",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionEnd:    time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	alice := createTestUser(t, store)
+	bob := createTestUser(t, store)
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+
+	placed, err := bs.SetMaxBid(ctx, productId, alice, money.Money{Amount: 5000, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("failed to set alice's max bid: %v", err)
+	}
+	if len(placed.Bids) != 1 || placed.Bids[0].BidAmount != 1100 || placed.Bids[0].BidderID != alice {
+		t.Fatalf("expected alice to open at 1100, got %+v", placed.Bids)
+	}
+
+	placed, err = bs.SetMaxBid(ctx, productId, bob, money.Money{Amount: 3000, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("failed to set bob's max bid: %v", err)
+	}
+
+	highest, err := store.GetHighestBidByProductId(ctx, productId)
+	if err != nil {
+		t.Fatalf("failed to get highest bid: %v", err)
+	}
+	if highest.BidderID != alice || highest.BidAmount != 3100 {
+		t.Fatalf("expected alice to lead at 3100, got %+v", highest)
+	}
+	if len(placed.Exceeded) != 1 || placed.Exceeded[0] != bob {
+		t.Fatalf("expected bob's max bid to be exceeded, got %v", placed.Exceeded)
+	}
+
+	placed, err = bs.PlaceBid(ctx, productId, bob, money.Money{Amount: 6000, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("failed to place bob's bid: %v", err)
+	}
+	if len(placed.Bids) != 1 || len(placed.Exceeded) != 1 || placed.Exceeded[0] != alice {
+		t.Fatalf("expected bob's bid to exceed alice's max bid, got %+v", placed)
+	}
+}
+
+func TestPlaceBidEnforcesIncrementSchedule(t *testing.T) {
+	forEachStore(t, testPlaceBidEnforcesIncrementSchedule)
+}
+
+func testPlaceBidEnforcesIncrementSchedule(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:    createTestUser(t, store),
+		ProductName: "tiered increments",
+		Description: "a product with a tiered increment schedule",
+		BasePrice:   1000,
+		Currency:    "USD",
+		AuctionType: pgstore.AuctionTypeEnglish,
+		AuctionEnd:  time.Now().Add(time.Hour),
+		BidIncrements: money.IncrementSchedule{
+			{From: 0, Increment: 100},
+			{From: 5000, Increment: 500},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bidder := createTestUser(t, store)
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+
+	if _, err := bs.PlaceBid(ctx, productId, bidder, money.Money{Amount: 5000, Currency: "USD"}); err != nil {
+		t.Fatalf("failed to place bid: %v", err)
+	}
+
+	_, err = bs.PlaceBid(ctx, productId, bidder, money.Money{Amount: 5100, Currency: "USD"})
+	var minimumBidError *MinimumBidError
+	if !errors.Is(err, ErrBidIncrementTooSmall) || !errors.As(err, &minimumBidError) {
+		t.Fatalf("expected ErrBidIncrementTooSmall, got %v", err)
+	}
+	if minimumBidError.Minimum.Amount != 5500 {
+		t.Fatalf("expected minimum next bid of 5500, got %d", minimumBidError.Minimum.Amount)
+	}
+
+	if _, err := bs.PlaceBid(ctx, productId, bidder, money.Money{Amount: 4000, Currency: "USD"}); !errors.Is(err, ErrBidIsTooLow) {
+		t.Fatalf("expected ErrBidIsTooLow, got %v", err)
+	}
+}
+
+func TestSettleHonorsReservePrice(t *testing.T) {
+	forEachStore(t, testSettleHonorsReservePrice)
+}
+
+func testSettleHonorsReservePrice(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      createTestUser(t, store),
+		ProductName:   "reserve price",
+		Description:   "a product with a reserve above its opening price",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		AuctionEnd:    time.Now().Add(time.Hour),
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		ReservePrice:  pgtype.Int8{Int64: 5000, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+	bidder := createTestUser(t, store)
+
+	placed, err := bs.PlaceBid(ctx, productId, bidder, money.Money{Amount: 2000, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("failed to place bid: %v", err)
+	}
+	if placed.ReserveMet {
+		t.Fatalf("expected reserve not to be met by a bid of 2000")
+	}
+
+	ss := NewSettlementService(store, clock.Real())
+	var notEndedError *AuctionNotEndedError
+	if _, err := ss.Settle(ctx, productId); !errors.As(err, &notEndedError) {
+		t.Fatalf("expected AuctionNotEndedError before the auction ends, got %v", err)
+	}
+
+	endTestAuction(t, store, productId)
+	result, err := ss.Settle(ctx, productId)
+	if err != nil {
+		t.Fatalf("failed to settle auction: %v", err)
+	}
+	if result.Status != AuctionResultReserveNotMet || result.WinnerID.Valid {
+		t.Fatalf("expected reserve_not_met without a winner, got %+v", result)
+	}
+
+	product, err := store.GetProductById(ctx, productId)
+	if err != nil {
+		t.Fatalf("failed to get product: %v", err)
+	}
+	if product.IsSold {
+		t.Fatalf("expected product not to be sold")
+	}
+}
+
+func TestBuyNowEndsAuction(t *testing.T) {
+	forEachStore(t, testBuyNowEndsAuction)
+}
+
+func testBuyNowEndsAuction(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      createTestUser(t, store),
+		ProductName:   "buy it now",
+		Description:   "a product that can be bought outright",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		AuctionEnd:    time.Now().Add(time.Hour),
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		BuyNowPrice:   pgtype.Int8{Int64: 9000, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+	buyer := createTestUser(t, store)
+
+	result, err := bs.BuyNow(ctx, productId, buyer)
+	if err != nil {
+		t.Fatalf("failed to buy now: %v", err)
+	}
+	if result.Status != AuctionResultBoughtNow || result.FinalPrice.Int64 != 9000 || result.WinnerID.Bytes != buyer {
+		t.Fatalf("unexpected auction result: %+v", result)
+	}
+
+	if _, err := bs.PlaceBid(ctx, productId, createTestUser(t, store), money.Money{Amount: 10000}); !errors.Is(err, ErrAuctionEnded) {
+		t.Fatalf("expected ErrAuctionEnded after buy now, got %v", err)
+	}
+	if _, err := bs.BuyNow(ctx, productId, buyer); !errors.Is(err, ErrAuctionEnded) {
+		t.Fatalf("expected ErrAuctionEnded on a second buy now, got %v", err)
+	}
+}
+
+func TestSettleSecondPriceChargesRunnerUp(t *testing.T) {
+	forEachStore(t, testSettleSecondPriceChargesRunnerUp)
+}
+
+func testSettleSecondPriceChargesRunnerUp(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      createTestUser(t, store),
+		ProductName:   "second price",
+		Description:   "a sealed second-price auction",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeSealedSecondPrice,
+		AuctionEnd:    time.Now().Add(time.Hour),
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+	winner := createTestUser(t, store)
+	runnerUp := createTestUser(t, store)
+
+	for _, bid := range []struct {
+		bidder uuid.UUID
+		amount int64
+	}{
+		{winner, 3000},
+		{runnerUp, 2500},
+		// Revising a sealed bid replaces the previous one, even downwards.
+		{winner, 4000},
+		{runnerUp, 2000},
+	} {
+		if _, err := bs.PlaceBid(ctx, productId, bid.bidder, money.Money{Amount: bid.amount, Currency: "USD"}); err != nil {
+			t.Fatalf("failed to place sealed bid: %v", err)
+		}
+	}
+
+	endTestAuction(t, store, productId)
+
+	ss := NewSettlementService(store, clock.Real())
+	result, err := ss.Settle(ctx, productId)
+	if err != nil {
+		t.Fatalf("failed to settle auction: %v", err)
+	}
+	if result.Status != AuctionResultSold || result.WinnerID.Bytes != winner || result.FinalPrice.Int64 != 2000 {
+		t.Fatalf("expected winner to pay the runner-up's 2000, got %+v", result)
+	}
+}
+
+func TestDutchPrice(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	product := pgstore.Product{
+		BasePrice:                1000,
+		AuctionStart:             start,
+		AuctionType:              pgstore.AuctionTypeDutch,
+		PriceDropAmount:          pgtype.Int8{Int64: 300, Valid: true},
+		PriceDropIntervalSeconds: pgtype.Int4{Int32: 60, Valid: true},
+		FloorPrice:               pgtype.Int8{Int64: 250, Valid: true},
+	}
+	floorOnStep := product
+	floorOnStep.FloorPrice = pgtype.Int8{Int64: 400, Valid: true}
+
+	tests := []struct {
+		name     string
+		product  pgstore.Product
+		elapsed  time.Duration
+		price    int64
+		nextDrop time.Duration
+		hasNext  bool
+	}{
+		{"before start", product, -time.Minute, 1000, time.Minute, true},
+		{"at start", product, 0, 1000, time.Minute, true},
+		{"after one drop", product, 90 * time.Second, 700, 2 * time.Minute, true},
+		{"above the floor", product, 2 * time.Minute, 400, 3 * time.Minute, true},
+		{"clamped to the floor", product, 3 * time.Minute, 250, 0, false},
+		{"long after reaching the floor", product, time.Hour, 250, 0, false},
+		{"with the floor on a step", floorOnStep, 2 * time.Minute, 400, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start.Add(tt.elapsed)
+			if price := DutchPrice(tt.product, now); price != tt.price {
+				t.Fatalf("expected price %d, got %d", tt.price, price)
+			}
+
+			next, ok := NextDutchPriceDrop(tt.product, now)
+			if ok != tt.hasNext {
+				t.Fatalf("expected next drop %v, got %v", tt.hasNext, ok)
+			}
+			if ok && !next.Equal(start.Add(tt.nextDrop)) {
+				t.Fatalf("expected next drop at %v, got %v", start.Add(tt.nextDrop), next)
+			}
+		})
+	}
+}
+
+func TestPlaceBidBeforeAuctionStart(t *testing.T) {
+	forEachStore(t, testPlaceBidBeforeAuctionStart)
+}
+
+func testPlaceBidBeforeAuctionStart(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      createTestUser(t, store),
+		ProductName:   "scheduled auction",
+		Description:   "a product whose auction starts later",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		AuctionStart:  time.Now().Add(time.Hour),
+		AuctionEnd:    time.Now().Add(3 * time.Hour),
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+	_, err = bs.PlaceBid(ctx, productId, createTestUser(t, store), money.Money{Amount: 2000, Currency: "USD"})
+	if !errors.Is(err, ErrAuctionNotStarted) {
+		t.Fatalf("expected ErrAuctionNotStarted, got %v", err)
+	}
+}
+
+func TestListBidHistoryLabelsBidders(t *testing.T) {
+	forEachStore(t, testListBidHistoryLabelsBidders)
+}
+
+func testListBidHistoryLabelsBidders(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	sellerId := createTestUser(t, store)
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      sellerId,
+		ProductName:   "bid history",
+		Description:   "a product with a few bids",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		AuctionEnd:    time.Now().Add(time.Hour),
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+	first := createTestUser(t, store)
+	second := createTestUser(t, store)
+	for i, bidder := range []uuid.UUID{first, second, first} {
+		amount := money.Money{Amount: int64(2000 + i*100), Currency: "USD"}
+		if _, err := bs.PlaceBid(ctx, productId, bidder, amount); err != nil {
+			t.Fatalf("failed to place bid: %v", err)
+		}
+	}
+
+	var history []BidHistoryEntry
+	query := BidHistoryQuery{Viewer: second, Limit: 2}
+	for {
+		page, err := bs.ListBidHistory(ctx, productId, query)
+		if err != nil {
+			t.Fatalf("failed to list bid history: %v", err)
+		}
+		history = append(history, page.Bids...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	wantBidders := []string{"Bidder 1", "Bidder 2", "Bidder 1"}
+	if len(history) != len(wantBidders) {
+		t.Fatalf("expected %d bids, got %d", len(wantBidders), len(history))
+	}
+	for i, entry := range history {
+		if entry.Bidder != wantBidders[i] || entry.UserName != "" || entry.Amount == nil {
+			t.Fatalf("unexpected entry %d: %+v", i, entry)
+		}
+		if entry.IsOwn != (i == 1) {
+			t.Fatalf("expected only the second bid to be flagged as own, got %+v", entry)
+		}
+	}
+
+	_, err = bs.ListBidHistory(ctx, productId, BidHistoryQuery{Viewer: second, ShowUserNames: true, Limit: 10})
+	if !errors.Is(err, ErrNotProductSeller) {
+		t.Fatalf("expected ErrNotProductSeller, got %v", err)
+	}
+
+	page, err := bs.ListBidHistory(ctx, productId, BidHistoryQuery{Viewer: sellerId, ShowUserNames: true, Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list bid history as the seller: %v", err)
+	}
+	for _, entry := range page.Bids {
+		if entry.UserName == "" {
+			t.Fatalf("expected the seller to see user names, got %+v", entry)
+		}
+	}
+}
+
+func TestListBidHistoryKeepsLabelsOfRevisedSealedBids(t *testing.T) {
+	forEachStore(t, testListBidHistoryKeepsLabelsOfRevisedSealedBids)
+}
+
+func testListBidHistoryKeepsLabelsOfRevisedSealedBids(t *testing.T, store pgstore.Store) {
+	ctx := context.Background()
+
+	sellerId := createTestUser(t, store)
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      sellerId,
+		ProductName:   "sealed bid history",
+		Description:   "a sealed auction whose first bidder revises their bid",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeSealedFirstPrice,
+		AuctionEnd:    time.Now().Add(time.Hour),
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	bs := NewBidsService(store, clock.Real(), SoftClose{})
+	first := createTestUser(t, store)
+	second := createTestUser(t, store)
+	for i, bidder := range []uuid.UUID{first, second, first} {
+		amount := money.Money{Amount: int64(2000 + i*100), Currency: "USD"}
+		if _, err := bs.PlaceBid(ctx, productId, bidder, amount); err != nil {
+			t.Fatalf("failed to place bid: %v", err)
+		}
+	}
+
+	page, err := bs.ListBidHistory(ctx, productId, BidHistoryQuery{Viewer: first, Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list bid history: %v", err)
+	}
+
+	// The revision replaced the first bidder's bid, but not their label.
+	if len(page.Bids) != 2 {
+		t.Fatalf("expected one bid per bidder, got %+v", page.Bids)
+	}
+	if revised := page.Bids[0]; revised.Bidder != "Bidder 1" || !revised.IsOwn {
+		t.Errorf("expected the revised bid to stay Bidder 1's, got %+v", revised)
+	}
+	if other := page.Bids[1]; other.Bidder != "Bidder 2" || other.IsOwn {
+		t.Errorf("expected the other bid to stay Bidder 2's, got %+v", other)
+	}
+}
+
+func TestListBidHistoryOrdersBidsPlacedAtOnce(t *testing.T) {
+	// A stopped clock gives every bid the same created_at, as now() does to
+	// the bids of a proxy war placed in one transaction.
+	t.Run("memstore", func(t *testing.T) {
+		clk := clock.NewFake(time.Now())
+		testListBidHistoryOrdersBidsPlacedAtOnce(t, memstore.NewWithClock(clk), clk)
+	})
+	t.Run("pgstore", func(t *testing.T) {
+		testListBidHistoryOrdersBidsPlacedAtOnce(t, pgstore.NewStore(newTestPool(t)), clock.Real())
+	})
+}
+
+func testListBidHistoryOrdersBidsPlacedAtOnce(t *testing.T, store pgstore.Store, clk clock.Clock) {
+	ctx := context.Background()
+
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:      createTestUser(t, store),
+		ProductName:   "proxy war history",
+		Description:   "a product whose proxy bids are placed together",
+		BasePrice:     1000,
+		Currency:      "USD",
+		AuctionType:   pgstore.AuctionTypeEnglish,
+		BidIncrements: money.IncrementSchedule{{From: 0, Increment: 100}},
+		AuctionEnd:    clk.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	alice := createTestUser(t, store)
+	bob := createTestUser(t, store)
+	bs := NewBidsService(store, clk, SoftClose{})
+
+	var placed []pgstore.Bid
+	for _, step := range []func() (PlacedBid, error){
+		func() (PlacedBid, error) {
+			return bs.SetMaxBid(ctx, productId, alice, money.Money{Amount: 5000, Currency: "USD"})
+		},
+		func() (PlacedBid, error) {
+			return bs.PlaceBid(ctx, productId, bob, money.Money{Amount: 2000, Currency: "USD"})
+		},
+		func() (PlacedBid, error) {
+			return bs.SetMaxBid(ctx, productId, bob, money.Money{Amount: 3000, Currency: "USD"})
+		},
+	} {
+		result, err := step()
+		if err != nil {
+			t.Fatalf("failed to bid: %v", err)
+		}
+		placed = append(placed, result.Bids...)
+	}
+	if len(placed) < 5 {
+		t.Fatalf("expected the proxy war to place at least 5 bids, got %+v", placed)
+	}
+
+	var history []BidHistoryEntry
+	query := BidHistoryQuery{Viewer: alice, Limit: 1}
+	for {
+		page, err := bs.ListBidHistory(ctx, productId, query)
+		if err != nil {
+			t.Fatalf("failed to list bid history: %v", err)
+		}
+		history = append(history, page.Bids...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	if len(history) != len(placed) {
+		t.Fatalf("expected %d bids, got %d", len(placed), len(history))
+	}
+	for i, entry := range history {
+		want := placed[len(placed)-1-i]
+		if entry.ID != want.ID || *entry.Amount != want.BidAmount {
+			t.Fatalf("expected bid %d to be %+v, got %+v", i, want, entry)
+		}
+	}
+}
@@ -2,47 +2,124 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"time"
 
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
 	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type ProductService struct {
-	pool    *pgxpool.Pool
-	queries *pgstore.Queries
+	store pgstore.Store
+	clock clock.Clock
 }
 
 var (
 	ErrProductNotFound = errors.New("product not found")
+	ErrInvalidCursor   = errors.New("invalid cursor")
 )
 
-func NewProductService(pool *pgxpool.Pool) ProductService {
+const (
+	ProductStatusScheduled = "scheduled"
+	ProductStatusActive    = "active"
+	ProductStatusEnded     = "ended"
+	ProductStatusSold      = "sold"
+)
+
+const (
+	ProductSortEndingSoon = "ending_soon"
+	ProductSortNewest     = "newest"
+	ProductSortPriceAsc   = "price_asc"
+	ProductSortPriceDesc  = "price_desc"
+)
+
+func NewProductService(store pgstore.Store, clk clock.Clock) ProductService {
 	return ProductService{
-		pool:    pool,
-		queries: pgstore.New(pool),
+		store: store,
+		clock: clk,
 	}
 }
 
-func (ps *ProductService) Create(
-	ctx context.Context,
-	selletrId uuid.UUID,
-	productName,
-	description string,
-	basePrice float64,
-	auctionEnd time.Time,
-) (uuid.UUID, error) {
-	id, err := ps.queries.CreateProduct(
+// NewProduct holds everything a seller chooses when listing a product.
+// ReservePrice and BuyNowPrice are optional, BidIncrements defaults to the
+// money.DefaultIncrementSchedule of the currency, AuctionType to an english
+// auction and AuctionStart to the time of creation. Dutch auctions drop their
+// price by PriceDrop every PriceDropInterval down to FloorPrice.
+type NewProduct struct {
+	SellerID      uuid.UUID
+	ProductName   string
+	Description   string
+	BasePrice     money.Money
+	ReservePrice  *money.Money
+	BuyNowPrice   *money.Money
+	AuctionStart  time.Time
+	AuctionEnd    time.Time
+	BidIncrements money.IncrementSchedule
+	AuctionType   pgstore.AuctionType
+
+	PriceDrop         *money.Money
+	PriceDropInterval time.Duration
+	FloorPrice        *money.Money
+}
+
+func (ps *ProductService) Create(ctx context.Context, product NewProduct) (uuid.UUID, error) {
+	bidIncrements := product.BidIncrements
+	if bidIncrements == nil {
+		bidIncrements = money.DefaultIncrementSchedule(product.BasePrice.Currency)
+	}
+
+	auctionType := product.AuctionType
+	if auctionType == "" {
+		auctionType = pgstore.AuctionTypeEnglish
+	}
+
+	auctionStart := product.AuctionStart
+	if auctionStart.IsZero() {
+		auctionStart = ps.clock.Now()
+	}
+
+	var reservePrice, buyNowPrice pgtype.Int8
+	if product.ReservePrice != nil {
+		reservePrice = pgtype.Int8{Int64: product.ReservePrice.Amount, Valid: true}
+	}
+	if product.BuyNowPrice != nil {
+		buyNowPrice = pgtype.Int8{Int64: product.BuyNowPrice.Amount, Valid: true}
+	}
+
+	var priceDropAmount, floorPrice pgtype.Int8
+	var priceDropInterval pgtype.Int4
+	if product.PriceDrop != nil {
+		priceDropAmount = pgtype.Int8{Int64: product.PriceDrop.Amount, Valid: true}
+		priceDropInterval = pgtype.Int4{Int32: int32(product.PriceDropInterval / time.Second), Valid: true}
+	}
+	if product.FloorPrice != nil {
+		floorPrice = pgtype.Int8{Int64: product.FloorPrice.Amount, Valid: true}
+	}
+
+	id, err := ps.store.CreateProduct(
 		ctx,
 		pgstore.CreateProductParams{
-			SellerID:    selletrId,
-			ProductName: productName,
-			Description: description,
-			BasePrice:   basePrice,
-			AuctionEnd:  auctionEnd,
+			SellerID:      product.SellerID,
+			ProductName:   product.ProductName,
+			Description:   product.Description,
+			BasePrice:     product.BasePrice.Amount,
+			Currency:      product.BasePrice.Currency,
+			AuctionStart:  auctionStart,
+			AuctionEnd:    product.AuctionEnd,
+			BidIncrements: bidIncrements,
+			ReservePrice:  reservePrice,
+			BuyNowPrice:   buyNowPrice,
+			AuctionType:   auctionType,
+
+			PriceDropAmount:          priceDropAmount,
+			PriceDropIntervalSeconds: priceDropInterval,
+			FloorPrice:               floorPrice,
 		},
 	)
 	if err != nil {
@@ -53,7 +130,7 @@ func (ps *ProductService) Create(
 }
 
 func (ps *ProductService) GetProductByID(ctx context.Context, id uuid.UUID) (pgstore.Product, error) {
-	product, err := ps.queries.GetProductById(ctx, id)
+	product, err := ps.store.GetProductById(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return pgstore.Product{}, ErrProductNotFound
@@ -64,3 +141,211 @@ func (ps *ProductService) GetProductByID(ctx context.Context, id uuid.UUID) (pgs
 
 	return product, nil
 }
+
+func (ps *ProductService) ListActiveProducts(ctx context.Context) ([]pgstore.Product, error) {
+	products, err := ps.store.ListActiveProducts(ctx, ps.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (ps *ProductService) ListScheduledProducts(ctx context.Context) ([]pgstore.Product, error) {
+	products, err := ps.store.ListScheduledProducts(ctx, ps.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (ps *ProductService) ListUnsettledEndedProducts(ctx context.Context) ([]pgstore.Product, error) {
+	products, err := ps.store.ListUnsettledEndedProducts(ctx, ps.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// ProductListing is a product as shown to bidders browsing auctions. HighBid
+// is omitted while there are no bids and in sealed auctions.
+type ProductListing struct {
+	pgstore.Product
+	Status       string `json:"status"`
+	CurrentPrice int64  `json:"current_price"`
+	HighBid      *int64 `json:"high_bid,omitempty"`
+	BidCount     int64  `json:"bid_count"`
+}
+
+func newProductListing(product pgstore.Product, highBid, bidCount, currentPrice int64, now time.Time) ProductListing {
+	listing := ProductListing{
+		Product:      product,
+		Status:       productStatus(product, now),
+		CurrentPrice: currentPrice,
+		BidCount:     bidCount,
+	}
+	if bidCount > 0 && !IsSealedAuction(product.AuctionType) {
+		listing.HighBid = &highBid
+	}
+
+	return listing
+}
+
+func productStatus(product pgstore.Product, now time.Time) string {
+	switch {
+	case product.IsSold:
+		return ProductStatusSold
+	case !now.Before(product.AuctionEnd):
+		return ProductStatusEnded
+	case now.Before(product.AuctionStart):
+		return ProductStatusScheduled
+	default:
+		return ProductStatusActive
+	}
+}
+
+// ProductFilter narrows and orders a product listing. Zero values disable a
+// filter; SortBy defaults to ProductSortEndingSoon. Cursor continues a listing
+// from the NextCursor of a previous page with the same SortBy.
+type ProductFilter struct {
+	Status       string
+	SellerID     *uuid.UUID
+	MinPrice     *int64
+	MaxPrice     *int64
+	EndingBefore *time.Time
+	Search       string
+	SortBy       string
+	Cursor       string
+	Limit        int32
+}
+
+// ProductPage is a page of listings; NextCursor is empty on the last page.
+type ProductPage struct {
+	Products   []ProductListing `json:"products"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// productCursor is the position of the last listing of a page in its sort
+// order.
+type productCursor struct {
+	SortBy  string    `json:"s"`
+	SortKey int64     `json:"k"`
+	ID      uuid.UUID `json:"id"`
+}
+
+// encodeCursor hands a keyset position to clients as opaque base64-encoded
+// JSON.
+func encodeCursor(cursor any) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string, cursor any) error {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
+
+// List returns a page of products matching filter using keyset pagination,
+// so pages stay consistent while new products and bids come in.
+func (ps *ProductService) List(ctx context.Context, filter ProductFilter) (ProductPage, error) {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = ProductSortEndingSoon
+	}
+
+	args := pgstore.ListProductsParams{
+		Now:      ps.clock.Now(),
+		SortBy:   sortBy,
+		PageSize: filter.Limit + 1,
+	}
+	if filter.Status != "" {
+		args.Status = pgtype.Text{String: filter.Status, Valid: true}
+	}
+	if filter.SellerID != nil {
+		args.SellerID = pgtype.UUID{Bytes: *filter.SellerID, Valid: true}
+	}
+	if filter.MinPrice != nil {
+		args.MinPrice = pgtype.Int8{Int64: *filter.MinPrice, Valid: true}
+	}
+	if filter.MaxPrice != nil {
+		args.MaxPrice = pgtype.Int8{Int64: *filter.MaxPrice, Valid: true}
+	}
+	if filter.EndingBefore != nil {
+		args.EndingBefore = pgtype.Timestamptz{Time: *filter.EndingBefore, Valid: true}
+	}
+	if filter.Search != "" {
+		args.Search = pgtype.Text{String: filter.Search, Valid: true}
+	}
+	if filter.Cursor != "" {
+		var cursor productCursor
+		if err := decodeCursor(filter.Cursor, &cursor); err != nil {
+			return ProductPage{}, err
+		}
+		if cursor.SortBy != sortBy {
+			return ProductPage{}, ErrInvalidCursor
+		}
+
+		args.AfterSortKey = pgtype.Int8{Int64: cursor.SortKey, Valid: true}
+		args.AfterID = pgtype.UUID{Bytes: cursor.ID, Valid: true}
+	}
+
+	rows, err := ps.store.ListProducts(ctx, args)
+	if err != nil {
+		return ProductPage{}, err
+	}
+
+	page := ProductPage{Products: make([]ProductListing, 0, len(rows))}
+	if len(rows) > int(filter.Limit) {
+		rows = rows[:filter.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(productCursor{SortBy: sortBy, SortKey: last.SortKey, ID: last.Product.ID})
+	}
+
+	for _, row := range rows {
+		page.Products = append(page.Products, newProductListing(row.Product, row.HighBid, row.BidCount, row.CurrentPrice, ps.clock.Now()))
+	}
+
+	return page, nil
+}
+
+// GetListingByID returns a product with its current price, high bid and bid
+// count.
+func (ps *ProductService) GetListingByID(ctx context.Context, id uuid.UUID) (ProductListing, error) {
+	row, err := ps.store.GetProductListingById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ProductListing{}, ErrProductNotFound
+		}
+
+		return ProductListing{}, err
+	}
+
+	now := ps.clock.Now()
+	return newProductListing(row.Product, row.HighBid, row.BidCount, currentPrice(row.Product, row.HighBid, row.BidCount, now), now), nil
+}
+
+// currentPrice mirrors the current_price column of ListProducts: the highest
+// bid of english auctions, the asking (or accepted) price of dutch auctions
+// and the base price of sealed auctions.
+func currentPrice(product pgstore.Product, highBid, bidCount int64, now time.Time) int64 {
+	switch {
+	case product.AuctionType == pgstore.AuctionTypeEnglish:
+		return max(product.BasePrice, highBid)
+	case product.AuctionType == pgstore.AuctionTypeDutch && bidCount > 0:
+		return highBid
+	case product.AuctionType == pgstore.AuctionTypeDutch:
+		return DutchPrice(product, now)
+	default:
+		return product.BasePrice
+	}
+}
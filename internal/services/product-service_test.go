@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestCreateProductAppliesDefaults(t *testing.T) {
+	forEachStore(t, testCreateProductAppliesDefaults)
+}
+
+func testCreateProductAppliesDefaults(t *testing.T, store pgstore.Store) {
+	ps := NewProductService(store, clock.Real())
+	ctx := context.Background()
+
+	before := time.Now().Add(-time.Second)
+	auctionEnd := time.Now().Add(3 * time.Hour)
+	id, err := ps.Create(ctx, NewProduct{
+		SellerID:    createTestUser(t, store),
+		ProductName: "pocket watch",
+		Description: "a product created with the defaults",
+		BasePrice:   money.Money{Amount: 5000, Currency: "EUR"},
+		AuctionEnd:  auctionEnd,
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	product, err := ps.GetProductByID(ctx, id)
+	if err != nil {
+		t.Fatalf("failed to get product: %v", err)
+	}
+	if product.AuctionType != pgstore.AuctionTypeEnglish {
+		t.Errorf("expected an english auction, got %s", product.AuctionType)
+	}
+	if product.BasePrice != 5000 || product.Currency != "EUR" {
+		t.Errorf("expected a base price of 5000 EUR, got %d %s", product.BasePrice, product.Currency)
+	}
+	if product.AuctionStart.Before(before) || product.AuctionStart.After(time.Now()) {
+		t.Errorf("expected the auction to start at creation, got %s", product.AuctionStart)
+	}
+	if d := product.AuctionEnd.Sub(auctionEnd); d < -time.Microsecond || d > time.Microsecond {
+		t.Errorf("expected the auction to end at %s, got %s", auctionEnd, product.AuctionEnd)
+	}
+	if product.ReservePrice.Valid || product.BuyNowPrice.Valid || product.IsSold {
+		t.Errorf("expected no reserve, no buy now price and an unsold product, got %+v", product)
+	}
+	if !slices.Equal(product.BidIncrements, money.DefaultIncrementSchedule("EUR")) {
+		t.Errorf("expected the default increment schedule, got %+v", product.BidIncrements)
+	}
+}
+
+func TestCreateProductScalesDefaultIncrementsToCurrency(t *testing.T) {
+	forEachStore(t, testCreateProductScalesDefaultIncrementsToCurrency)
+}
+
+func testCreateProductScalesDefaultIncrementsToCurrency(t *testing.T, store pgstore.Store) {
+	ps := NewProductService(store, clock.Real())
+	ctx := context.Background()
+	sellerId := createTestUser(t, store)
+
+	minimums := make(map[string]int64)
+	for _, currency := range []string{"USD", "JPY"} {
+		id, err := ps.Create(ctx, NewProduct{
+			SellerID:    sellerId,
+			ProductName: "tea set",
+			Description: "a product priced in " + currency,
+			BasePrice:   money.Money{Amount: 5000, Currency: currency},
+			AuctionEnd:  time.Now().Add(3 * time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("failed to create product: %v", err)
+		}
+
+		product, err := ps.GetProductByID(ctx, id)
+		if err != nil {
+			t.Fatalf("failed to get product: %v", err)
+		}
+		minimums[currency] = product.BidIncrements.NextMinimum(product.BasePrice)
+	}
+
+	// 50.00 USD takes a raise of 1.00, and 5000 JPY one of 500 yen.
+	if minimums["USD"] != 5100 || minimums["JPY"] != 5500 {
+		t.Errorf("unexpected minimum bids %v", minimums)
+	}
+}
+
+func TestCreateProductRequiresExistingSeller(t *testing.T) {
+	forEachStore(t, testCreateProductRequiresExistingSeller)
+}
+
+func testCreateProductRequiresExistingSeller(t *testing.T, store pgstore.Store) {
+	ps := NewProductService(store, clock.Real())
+	ctx := context.Background()
+
+	_, err := ps.Create(ctx, NewProduct{
+		SellerID:    uuid.New(),
+		ProductName: "orphan",
+		Description: "a product without a seller",
+		BasePrice:   money.Money{Amount: 1000, Currency: "USD"},
+		AuctionEnd:  time.Now().Add(3 * time.Hour),
+	})
+	var pgError *pgconn.PgError
+	if !errors.As(err, &pgError) || pgError.Code != "23503" {
+		t.Fatalf("expected a foreign key violation, got %v", err)
+	}
+
+	if _, err := ps.GetProductByID(ctx, uuid.New()); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestListProductsPaginatesWithKeyset(t *testing.T) {
+	forEachStore(t, testListProductsPaginatesWithKeyset)
+}
+
+func testListProductsPaginatesWithKeyset(t *testing.T, store pgstore.Store) {
+	ps := NewProductService(store, clock.Real())
+	ctx := context.Background()
+
+	sellerId := createTestUser(t, store)
+	var created []uuid.UUID
+	for i := range 5 {
+		id, err := ps.Create(ctx, NewProduct{
+			SellerID:    sellerId,
+			ProductName: "vintage telescope",
+			Description: "a brass telescope listed for pagination",
+			BasePrice:   money.Money{Amount: int64(1000 + i), Currency: "USD"},
+			AuctionEnd:  time.Now().Add(time.Duration(i+3) * time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("failed to create product: %v", err)
+		}
+		created = append(created, id)
+	}
+
+	filter := ProductFilter{
+		SellerID: &sellerId,
+		Search:   "telescope",
+		SortBy:   ProductSortPriceDesc,
+		Limit:    2,
+	}
+
+	var listed []uuid.UUID
+	for range 3 {
+		page, err := ps.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("failed to list products: %v", err)
+		}
+		for _, product := range page.Products {
+			if product.Status != ProductStatusActive || product.HighBid != nil {
+				t.Fatalf("unexpected listing %+v", product)
+			}
+			listed = append(listed, product.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	if len(listed) != len(created) {
+		t.Fatalf("expected %d products, got %d", len(created), len(listed))
+	}
+	for i, id := range listed {
+		if want := created[len(created)-1-i]; id != want {
+			t.Fatalf("expected product %d to be %s, got %s", i, want, id)
+		}
+	}
+
+	filter.SortBy = ProductSortNewest
+	if _, err := ps.List(ctx, filter); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor for a cursor of another sort, got %v", err)
+	}
+}
+
+func TestListProductsSeparatesEndedFromSold(t *testing.T) {
+	forEachStore(t, testListProductsSeparatesEndedFromSold)
+}
+
+func testListProductsSeparatesEndedFromSold(t *testing.T, store pgstore.Store) {
+	ps := NewProductService(store, clock.Real())
+	ctx := context.Background()
+
+	sellerId := createTestUser(t, store)
+	var ended []uuid.UUID
+	for range 2 {
+		id, err := ps.Create(ctx, NewProduct{
+			SellerID:     sellerId,
+			ProductName:  "marble bust",
+			Description:  "a product whose auction is over",
+			BasePrice:    money.Money{Amount: 1000, Currency: "USD"},
+			AuctionStart: time.Now().Add(-2 * time.Hour),
+			AuctionEnd:   time.Now().Add(-time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("failed to create product: %v", err)
+		}
+		ended = append(ended, id)
+	}
+	if err := store.MarkProductAsSold(ctx, ended[1]); err != nil {
+		t.Fatalf("failed to mark product as sold: %v", err)
+	}
+
+	for status, want := range map[string]uuid.UUID{
+		ProductStatusEnded: ended[0],
+		ProductStatusSold:  ended[1],
+	} {
+		page, err := ps.List(ctx, ProductFilter{Status: status, SellerID: &sellerId, Limit: 10})
+		if err != nil {
+			t.Fatalf("failed to list %s products: %v", status, err)
+		}
+		if len(page.Products) != 1 || page.Products[0].ID != want || page.Products[0].Status != status {
+			t.Errorf("expected only %s to be %s, got %+v", want, status, page.Products)
+		}
+	}
+}
+
+func TestListProductsFollowsServiceClock(t *testing.T) {
+	forEachStore(t, testListProductsFollowsServiceClock)
+}
+
+func testListProductsFollowsServiceClock(t *testing.T, store pgstore.Store) {
+	// Stored times lose their nanoseconds, which would cost a price drop.
+	clk := clock.NewFake(time.Now().Truncate(time.Second))
+	ps := NewProductService(store, clk)
+	ctx := context.Background()
+
+	sellerId := createTestUser(t, store)
+	id, err := ps.Create(ctx, NewProduct{
+		SellerID:          sellerId,
+		ProductName:       "grandfather clock",
+		Description:       "a dutch auction that starts in an hour",
+		BasePrice:         money.Money{Amount: 10000, Currency: "USD"},
+		AuctionStart:      clk.Now().Add(time.Hour),
+		AuctionEnd:        clk.Now().Add(3 * time.Hour),
+		AuctionType:       pgstore.AuctionTypeDutch,
+		PriceDrop:         &money.Money{Amount: 500, Currency: "USD"},
+		PriceDropInterval: 10 * time.Minute,
+		FloorPrice:        &money.Money{Amount: 5000, Currency: "USD"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	contains := func(products []pgstore.Product) bool {
+		return slices.ContainsFunc(products, func(p pgstore.Product) bool { return p.ID == id })
+	}
+	listed := func(list func(context.Context) ([]pgstore.Product, error)) bool {
+		t.Helper()
+		products, err := list(ctx)
+		if err != nil {
+			t.Fatalf("failed to list products: %v", err)
+		}
+		return contains(products)
+	}
+
+	if !listed(ps.ListScheduledProducts) || listed(ps.ListActiveProducts) {
+		t.Fatalf("expected the product to be scheduled before its start")
+	}
+
+	// The store's own clock has not moved, only the service's.
+	clk.Advance(2 * time.Hour)
+	if listed(ps.ListScheduledProducts) || !listed(ps.ListActiveProducts) {
+		t.Fatalf("expected the product to be active after its start")
+	}
+	page, err := ps.List(ctx, ProductFilter{Status: ProductStatusActive, SellerID: &sellerId, Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list active products: %v", err)
+	}
+	if len(page.Products) != 1 || page.Products[0].ID != id || page.Products[0].CurrentPrice != 7000 {
+		t.Fatalf("expected the product to be active at 7000 an hour into its auction, got %+v", page.Products)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if listed(ps.ListActiveProducts) || !listed(ps.ListUnsettledEndedProducts) {
+		t.Fatalf("expected the product to be unsettled after its end")
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PubSubService fans auction events out to every API instance through
+// Postgres LISTEN/NOTIFY, so each instance keeps a replica of every room for
+// its own clients.
+//
+// Bids are validated by the database, so any replica may accept them. Room
+// timers are the only thing that needs a single writer: the instance holding
+// the leader advisory lock settles auctions when they end and drops dutch
+// auction prices, then publishes the outcome to the other replicas.
+type PubSubService struct {
+	pool       *pgxpool.Pool
+	queries    *pgstore.Queries
+	instanceID uuid.UUID
+
+	mu        sync.Mutex
+	isLeader  bool
+	leaderSig chan struct{}
+}
+
+type AuctionEventKind string
+
+const (
+	// AuctionEventOpened asks replicas to open (or schedule) a new room.
+	AuctionEventOpened AuctionEventKind = "opened"
+	// AuctionEventBidsPlaced carries bids accepted by another replica.
+	AuctionEventBidsPlaced AuctionEventKind = "bids_placed"
+	// AuctionEventPriceDropped carries the new asking price of a dutch auction.
+	AuctionEventPriceDropped AuctionEventKind = "price_dropped"
+	// AuctionEventEnded tells replicas that the auction has been settled.
+	AuctionEventEnded AuctionEventKind = "ended"
+)
+
+// AuctionEvent is the NOTIFY payload shared by all instances.
+type AuctionEvent struct {
+	InstanceID  uuid.UUID        `json:"instance_id"`
+	Kind        AuctionEventKind `json:"kind"`
+	ProductID   uuid.UUID        `json:"product_id"`
+	Placed      *PlacedBid       `json:"placed,omitempty"`
+	FirstByHand bool             `json:"first_by_hand,omitempty"`
+	Price       int64            `json:"price,omitempty"`
+}
+
+const (
+	auctionEventsChannel = "gobid_auction_events"
+	// leaderLockKey identifies the session-level advisory lock held by the
+	// leader instance.
+	leaderLockKey       int64 = 0x676f626964 // "gobid"
+	leaderCheckInterval       = 5 * time.Second
+	listenRetryInterval       = time.Second
+)
+
+func NewPubSubService(pool *pgxpool.Pool) *PubSubService {
+	return &PubSubService{
+		pool:       pool,
+		queries:    pgstore.New(pool),
+		instanceID: uuid.New(),
+		leaderSig:  make(chan struct{}),
+	}
+}
+
+// IsLeader reports whether this instance currently runs the room timers. A nil
+// service stands for a single instance, which always leads.
+func (ps *PubSubService) IsLeader() bool {
+	if ps == nil {
+		return true
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.isLeader
+}
+
+// LeadershipChanged returns a channel that is closed the next time this
+// instance gains or loses leadership. It is nil, and never fires, for a nil
+// service.
+func (ps *PubSubService) LeadershipChanged() <-chan struct{} {
+	if ps == nil {
+		return nil
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.leaderSig
+}
+
+func (ps *PubSubService) setLeader(isLeader bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.isLeader == isLeader {
+		return
+	}
+
+	slog.Info("Leadership changed", "instanceID", ps.instanceID, "isLeader", isLeader)
+	ps.isLeader = isLeader
+	close(ps.leaderSig)
+	ps.leaderSig = make(chan struct{})
+}
+
+// Publish sends event to the other instances. Events published by a nil
+// service go nowhere.
+func (ps *PubSubService) Publish(ctx context.Context, event AuctionEvent) error {
+	if ps == nil {
+		return nil
+	}
+
+	event.InstanceID = ps.instanceID
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return ps.queries.Notify(ctx, pgstore.NotifyParams{
+		Channel: auctionEventsChannel,
+		Payload: string(payload),
+	})
+}
+
+// Listen calls handle with every event published by the other instances until
+// ctx is done, reconnecting whenever the listening connection is lost.
+func (ps *PubSubService) Listen(ctx context.Context, handle func(AuctionEvent)) {
+	for {
+		err := ps.listen(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Error("Lost auction events listener", "error", err)
+		select {
+		case <-time.After(listenRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (ps *PubSubService) listen(ctx context.Context, handle func(AuctionEvent)) error {
+	conn, err := ps.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection is closed rather than returned to the pool so it does not
+	// keep listening.
+	defer func() {
+		conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+auctionEventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event AuctionEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			slog.Error("Invalid auction event", "payload", notification.Payload, "error", err)
+			continue
+		}
+
+		if event.InstanceID == ps.instanceID {
+			continue
+		}
+
+		handle(event)
+	}
+}
+
+// RunLeaderElection competes for the leader advisory lock until ctx is done.
+// The lock is held by a dedicated connection, so it is released by Postgres
+// as soon as the leader goes away and another instance takes over on its next
+// attempt.
+func (ps *PubSubService) RunLeaderElection(ctx context.Context) {
+	ticker := time.NewTicker(leaderCheckInterval)
+	defer ticker.Stop()
+
+	var conn *pgxpool.Conn
+	defer func() {
+		if conn != nil {
+			ps.resign(conn)
+		}
+	}()
+
+	for {
+		if conn == nil {
+			conn = ps.tryLead(ctx)
+		} else if err := conn.Ping(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Lost leader connection", "error", err)
+			conn.Conn().Close(context.Background())
+			conn.Release()
+			conn = nil
+			ps.setLeader(false)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (ps *PubSubService) tryLead(ctx context.Context) *pgxpool.Conn {
+	conn, err := ps.pool.Acquire(ctx)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Failed to acquire leader connection", "error", err)
+		}
+		return nil
+	}
+
+	locked, err := pgstore.New(conn).TryAdvisoryLock(ctx, leaderLockKey)
+	if err != nil || !locked {
+		if err != nil {
+			slog.Error("Failed to try leader lock", "error", err)
+		}
+		conn.Release()
+		return nil
+	}
+
+	ps.setLeader(true)
+	return conn
+}
+
+func (ps *PubSubService) resign(conn *pgxpool.Conn) {
+	ps.setLeader(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaderCheckInterval)
+	defer cancel()
+
+	if _, err := pgstore.New(conn).AdvisoryUnlock(ctx, leaderLockKey); err != nil {
+		conn.Conn().Close(ctx)
+	}
+	conn.Release()
+}
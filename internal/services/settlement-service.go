@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SettlementService struct {
+	store pgstore.Store
+	clock clock.Clock
+}
+
+const (
+	AuctionResultSold          = "sold"
+	AuctionResultBoughtNow     = "bought_now"
+	AuctionResultNoBids        = "no_bids"
+	AuctionResultReserveNotMet = "reserve_not_met"
+)
+
+var (
+	ErrAuctionNotSettled = errors.New("auction has not been settled yet")
+)
+
+// AuctionNotEndedError refuses to settle an auction before its end, e.g. when
+// a late bid on another instance has extended it.
+type AuctionNotEndedError struct {
+	AuctionEnd time.Time
+}
+
+func (e *AuctionNotEndedError) Error() string {
+	return "auction has not ended yet"
+}
+
+func NewSettlementService(store pgstore.Store, clk clock.Clock) SettlementService {
+	return SettlementService{
+		store: store,
+		clock: clk,
+	}
+}
+
+// Settle records the outcome of a finished auction and marks the product as
+// sold when its highest bid meets the reserve price. The highest bid wins (the
+// earliest one on ties) and pays the price given by the auction format.
+// Settling an auction twice returns the first result, and auctions that are
+// still running are left untouched.
+func (ss *SettlementService) Settle(ctx context.Context, productId uuid.UUID) (pgstore.AuctionResult, error) {
+	qtx, err := ss.store.Begin(ctx)
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+	defer qtx.Rollback(ctx)
+
+	product, err := qtx.GetProductByIdForUpdate(ctx, productId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgstore.AuctionResult{}, ErrProductNotFound
+		}
+
+		return pgstore.AuctionResult{}, err
+	}
+
+	result, err := qtx.GetAuctionResultByProductId(ctx, productId)
+	if err == nil {
+		return result, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return pgstore.AuctionResult{}, err
+	}
+
+	if ss.clock.Now().Before(product.AuctionEnd) {
+		return pgstore.AuctionResult{}, &AuctionNotEndedError{AuctionEnd: product.AuctionEnd}
+	}
+
+	args := pgstore.CreateAuctionResultParams{
+		ProductID: productId,
+		Currency:  product.Currency,
+		Status:    AuctionResultNoBids,
+	}
+
+	topBids, err := qtx.ListTopBidsByProductId(ctx, pgstore.ListTopBidsByProductIdParams{
+		ProductID: productId,
+		Limit:     2,
+	})
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	switch {
+	case len(topBids) == 0:
+	case product.ReservePrice.Valid && topBids[0].BidAmount < product.ReservePrice.Int64:
+		args.Status = AuctionResultReserveNotMet
+	default:
+		winningBid := topBids[0]
+		args.Status = AuctionResultSold
+		args.WinnerID = pgtype.UUID{Bytes: winningBid.BidderID, Valid: true}
+		args.WinningBidID = pgtype.UUID{Bytes: winningBid.ID, Valid: true}
+		args.FinalPrice = pgtype.Int8{Int64: clearingPrice(product, topBids), Valid: true}
+
+		if err := qtx.MarkProductAsSold(ctx, productId); err != nil {
+			return pgstore.AuctionResult{}, err
+		}
+	}
+
+	result, err = qtx.CreateAuctionResult(ctx, args)
+	if err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	if err := qtx.Commit(ctx); err != nil {
+		return pgstore.AuctionResult{}, err
+	}
+
+	return result, nil
+}
+
+// clearingPrice is what the winner of topBids (ordered from the highest) pays.
+// Second-price auctions charge the runner-up's bid, but never less than the
+// base price or the reserve price; every other format charges the winning bid.
+func clearingPrice(product pgstore.Product, topBids []pgstore.Bid) int64 {
+	if product.AuctionType != pgstore.AuctionTypeSealedSecondPrice {
+		return topBids[0].BidAmount
+	}
+
+	price := product.BasePrice
+	if len(topBids) > 1 {
+		price = max(price, topBids[1].BidAmount)
+	}
+	if product.ReservePrice.Valid {
+		price = max(price, product.ReservePrice.Int64)
+	}
+
+	return price
+}
+
+func (ss *SettlementService) GetResultByProductID(ctx context.Context, productId uuid.UUID) (pgstore.AuctionResult, error) {
+	result, err := ss.store.GetAuctionResultByProductId(ctx, productId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgstore.AuctionResult{}, ErrAuctionNotSettled
+		}
+
+		return pgstore.AuctionResult{}, err
+	}
+
+	return result, nil
+}
@@ -8,13 +8,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
-	pool    *pgxpool.Pool
-	queries *pgstore.Queries
+	store pgstore.Store
 }
 
 var (
@@ -22,10 +20,9 @@ var (
 	ErrInvalidCredentials        = errors.New("invalid credentials")
 )
 
-func NewUserService(pool *pgxpool.Pool) UserService {
+func NewUserService(store pgstore.Store) UserService {
 	return UserService{
-		pool:    pool,
-		queries: pgstore.New(pool),
+		store: store,
 	}
 }
 
@@ -42,7 +39,7 @@ func (us *UserService) CreateUser(ctx context.Context, userName, email, password
 		Bio:          bio,
 	}
 
-	id, err := us.queries.CreateUser(ctx, args)
+	id, err := us.store.CreateUser(ctx, args)
 	if err != nil {
 		var pgError *pgconn.PgError
 		if errors.As(err, &pgError) && pgError.Code == "23505" { // <- postgres code of unique constraint violation
@@ -56,7 +53,7 @@ func (us *UserService) CreateUser(ctx context.Context, userName, email, password
 }
 
 func (us *UserService) AuthenticateUser(ctx context.Context, email, password string) (uuid.UUID, error) {
-	user, err := us.queries.GetUserByEmail(ctx, email)
+	user, err := us.store.GetUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return uuid.UUID{}, ErrInvalidCredentials
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+)
+
+func TestCreateUserRejectsDuplicates(t *testing.T) {
+	forEachStore(t, testCreateUserRejectsDuplicates)
+}
+
+func testCreateUserRejectsDuplicates(t *testing.T, store pgstore.Store) {
+	us := NewUserService(store)
+	ctx := context.Background()
+
+	name := uuid.NewString()
+	if _, err := us.CreateUser(ctx, name, name+"@gobid.test", "password", "bio"); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	other := uuid.NewString()
+	if _, err := us.CreateUser(ctx, name, other+"@gobid.test", "password", "bio"); !errors.Is(err, ErrDuplicatedEmailOrUsername) {
+		t.Errorf("expected ErrDuplicatedEmailOrUsername for a taken user name, got %v", err)
+	}
+	if _, err := us.CreateUser(ctx, other, name+"@gobid.test", "password", "bio"); !errors.Is(err, ErrDuplicatedEmailOrUsername) {
+		t.Errorf("expected ErrDuplicatedEmailOrUsername for a taken email, got %v", err)
+	}
+	if _, err := us.CreateUser(ctx, other, other+"@gobid.test", "password", "bio"); err != nil {
+		t.Errorf("expected a new user name and email to be accepted, got %v", err)
+	}
+}
+
+func TestAuthenticateUser(t *testing.T) {
+	forEachStore(t, testAuthenticateUser)
+}
+
+func testAuthenticateUser(t *testing.T, store pgstore.Store) {
+	us := NewUserService(store)
+	ctx := context.Background()
+
+	name := uuid.NewString()
+	email := name + "@gobid.test"
+	id, err := us.CreateUser(ctx, name, email, "correct horse", "bio")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	authenticated, err := us.AuthenticateUser(ctx, email, "correct horse")
+	if err != nil {
+		t.Fatalf("failed to authenticate: %v", err)
+	}
+	if authenticated != id {
+		t.Errorf("expected user %s, got %s", id, authenticated)
+	}
+
+	if _, err := us.AuthenticateUser(ctx, email, "battery staple"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for a wrong password, got %v", err)
+	}
+	if _, err := us.AuthenticateUser(ctx, uuid.NewString()+"@gobid.test", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for an unknown email, got %v", err)
+	}
+}
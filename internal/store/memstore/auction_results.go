@@ -0,0 +1,62 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (q *queries) CreateAuctionResult(ctx context.Context, arg pgstore.CreateAuctionResultParams) (pgstore.AuctionResult, error) {
+	defer q.lock()()
+
+	t := q.tables()
+	if q.productIndex(arg.ProductID) < 0 {
+		return pgstore.AuctionResult{}, violation(foreignKeyViolation, "auction_results_product_id_fkey", `insert or update on table "auction_results" violates foreign key constraint "auction_results_product_id_fkey"`)
+	}
+	if arg.WinnerID.Valid && !q.userExists(arg.WinnerID.Bytes) {
+		return pgstore.AuctionResult{}, violation(foreignKeyViolation, "auction_results_winner_id_fkey", `insert or update on table "auction_results" violates foreign key constraint "auction_results_winner_id_fkey"`)
+	}
+	if arg.WinningBidID.Valid && q.bidIndex(arg.WinningBidID.Bytes) < 0 {
+		return pgstore.AuctionResult{}, violation(foreignKeyViolation, "auction_results_winning_bid_id_fkey", `insert or update on table "auction_results" violates foreign key constraint "auction_results_winning_bid_id_fkey"`)
+	}
+	for _, result := range t.auctionResults {
+		if result.ProductID == arg.ProductID {
+			return pgstore.AuctionResult{}, violation(uniqueViolation, "auction_results_pkey", `duplicate key value violates unique constraint "auction_results_pkey"`)
+		}
+	}
+
+	result := pgstore.AuctionResult{
+		ProductID:    arg.ProductID,
+		WinnerID:     arg.WinnerID,
+		WinningBidID: arg.WinningBidID,
+		FinalPrice:   arg.FinalPrice,
+		Status:       arg.Status,
+		SettledAt:    q.now(),
+		Currency:     arg.Currency,
+	}
+	t.auctionResults = append(t.auctionResults, result)
+
+	return result, nil
+}
+
+func (q *queries) GetAuctionResultByProductId(ctx context.Context, productID uuid.UUID) (pgstore.AuctionResult, error) {
+	defer q.lock()()
+
+	if result, ok := q.auctionResult(productID); ok {
+		return result, nil
+	}
+
+	return pgstore.AuctionResult{}, pgx.ErrNoRows
+}
+
+func (q *queries) auctionResult(productID uuid.UUID) (pgstore.AuctionResult, bool) {
+	for _, result := range q.tables().auctionResults {
+		if result.ProductID == productID {
+			return result, true
+		}
+	}
+
+	return pgstore.AuctionResult{}, false
+}
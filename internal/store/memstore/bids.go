@@ -0,0 +1,190 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (q *queries) CreateBid(ctx context.Context, arg pgstore.CreateBidParams) (pgstore.Bid, error) {
+	defer q.lock()()
+
+	t := q.tables()
+	if q.productIndex(arg.ProductID) < 0 {
+		return pgstore.Bid{}, violation(foreignKeyViolation, "bids_product_id_fkey", `insert or update on table "bids" violates foreign key constraint "bids_product_id_fkey"`)
+	}
+	if !q.userExists(arg.BidderID) {
+		return pgstore.Bid{}, violation(foreignKeyViolation, "bids_bidder_id_fkey", `insert or update on table "bids" violates foreign key constraint "bids_bidder_id_fkey"`)
+	}
+
+	t.bidSeq++
+	bid := pgstore.Bid{
+		ID:        uuid.New(),
+		ProductID: arg.ProductID,
+		BidderID:  arg.BidderID,
+		BidAmount: arg.BidAmount,
+		CreatedAt: q.now(),
+		Seq:       t.bidSeq,
+	}
+	t.bids = append(t.bids, bid)
+
+	if q.bidderNumber(arg.ProductID, arg.BidderID) == 0 {
+		var last int64
+		for _, bidder := range t.auctionBidders {
+			if bidder.ProductID == arg.ProductID {
+				last = max(last, bidder.BidderNumber)
+			}
+		}
+		t.auctionBidders = append(t.auctionBidders, pgstore.AuctionBidder{
+			ProductID:    arg.ProductID,
+			BidderID:     arg.BidderID,
+			BidderNumber: last + 1,
+			CreatedAt:    bid.CreatedAt,
+		})
+	}
+
+	return bid, nil
+}
+
+func (q *queries) GetBidsByProductId(ctx context.Context, productID uuid.UUID) ([]pgstore.Bid, error) {
+	defer q.lock()()
+
+	bids := q.bidsOf(productID)
+	slices.SortStableFunc(bids, func(a, b pgstore.Bid) int {
+		return cmp.Compare(b.BidAmount, a.BidAmount)
+	})
+
+	return bids, nil
+}
+
+func (q *queries) GetHighestBidByProductId(ctx context.Context, productID uuid.UUID) (pgstore.Bid, error) {
+	defer q.lock()()
+
+	bids := q.topBids(productID)
+	if len(bids) == 0 {
+		return pgstore.Bid{}, pgx.ErrNoRows
+	}
+
+	return bids[0], nil
+}
+
+func (q *queries) ListTopBidsByProductId(ctx context.Context, arg pgstore.ListTopBidsByProductIdParams) ([]pgstore.Bid, error) {
+	defer q.lock()()
+
+	bids := q.topBids(arg.ProductID)
+	if len(bids) > int(arg.Limit) {
+		bids = bids[:arg.Limit]
+	}
+
+	return bids, nil
+}
+
+func (q *queries) DeleteBidsByProductIdAndBidderId(ctx context.Context, arg pgstore.DeleteBidsByProductIdAndBidderIdParams) error {
+	defer q.lock()()
+
+	t := q.tables()
+	t.bids = slices.DeleteFunc(t.bids, func(b pgstore.Bid) bool {
+		return b.ProductID == arg.ProductID && b.BidderID == arg.BidderID
+	})
+
+	return nil
+}
+
+func (q *queries) ListBidHistoryByProductId(ctx context.Context, arg pgstore.ListBidHistoryByProductIdParams) ([]pgstore.ListBidHistoryByProductIdRow, error) {
+	defer q.lock()()
+
+	bids := q.bidsOf(arg.ProductID)
+
+	userNames := make(map[uuid.UUID]string)
+	for _, user := range q.tables().users {
+		userNames[user.ID] = user.UserName
+	}
+
+	slices.SortFunc(bids, func(a, b pgstore.Bid) int {
+		return cmp.Compare(b.Seq, a.Seq)
+	})
+
+	var rows []pgstore.ListBidHistoryByProductIdRow
+	for _, bid := range bids {
+		if len(rows) == int(arg.PageSize) {
+			break
+		}
+		if arg.BeforeSeq.Valid && bid.Seq >= arg.BeforeSeq.Int64 {
+			continue
+		}
+
+		rows = append(rows, pgstore.ListBidHistoryByProductIdRow{
+			ID:           bid.ID,
+			BidderID:     bid.BidderID,
+			BidAmount:    bid.BidAmount,
+			CreatedAt:    bid.CreatedAt,
+			Seq:          bid.Seq,
+			UserName:     userNames[bid.BidderID],
+			BidderNumber: q.bidderNumber(arg.ProductID, bid.BidderID),
+		})
+	}
+
+	return rows, nil
+}
+
+func (q *queries) GetBidStatsByProductId(ctx context.Context, productID uuid.UUID) (pgstore.GetBidStatsByProductIdRow, error) {
+	defer q.lock()()
+
+	return q.bidStats(productID), nil
+}
+
+// bidderNumber returns the number of the bidder in the product's auction, or
+// zero before their first bid.
+func (q *queries) bidderNumber(productID, bidderID uuid.UUID) int64 {
+	for _, bidder := range q.tables().auctionBidders {
+		if bidder.ProductID == productID && bidder.BidderID == bidderID {
+			return bidder.BidderNumber
+		}
+	}
+
+	return 0
+}
+
+func (q *queries) bidsOf(productID uuid.UUID) []pgstore.Bid {
+	var bids []pgstore.Bid
+	for _, bid := range q.tables().bids {
+		if bid.ProductID == productID {
+			bids = append(bids, bid)
+		}
+	}
+
+	return bids
+}
+
+// topBids orders the bids on a product by amount, earliest first on ties.
+func (q *queries) topBids(productID uuid.UUID) []pgstore.Bid {
+	bids := q.bidsOf(productID)
+	slices.SortStableFunc(bids, func(a, b pgstore.Bid) int {
+		if a.BidAmount != b.BidAmount {
+			return cmp.Compare(b.BidAmount, a.BidAmount)
+		}
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return bids
+}
+
+func (q *queries) bidStats(productID uuid.UUID) pgstore.GetBidStatsByProductIdRow {
+	var stats pgstore.GetBidStatsByProductIdRow
+	for _, bid := range q.tables().bids {
+		if bid.ProductID == productID {
+			stats.HighBid = max(stats.HighBid, bid.BidAmount)
+			stats.BidCount++
+		}
+	}
+
+	return stats
+}
+
+func (q *queries) bidIndex(id uuid.UUID) int {
+	return slices.IndexFunc(q.tables().bids, func(b pgstore.Bid) bool { return b.ID == id })
+}
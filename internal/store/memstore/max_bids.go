@@ -0,0 +1,66 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+)
+
+func (q *queries) UpsertMaxBid(ctx context.Context, arg pgstore.UpsertMaxBidParams) (pgstore.MaxBid, error) {
+	defer q.lock()()
+
+	t := q.tables()
+	if q.productIndex(arg.ProductID) < 0 {
+		return pgstore.MaxBid{}, violation(foreignKeyViolation, "max_bids_product_id_fkey", `insert or update on table "max_bids" violates foreign key constraint "max_bids_product_id_fkey"`)
+	}
+	if !q.userExists(arg.BidderID) {
+		return pgstore.MaxBid{}, violation(foreignKeyViolation, "max_bids_bidder_id_fkey", `insert or update on table "max_bids" violates foreign key constraint "max_bids_bidder_id_fkey"`)
+	}
+
+	now := q.now()
+	for i, maxBid := range t.maxBids {
+		if maxBid.ProductID == arg.ProductID && maxBid.BidderID == arg.BidderID {
+			t.maxBids[i].MaxAmount = arg.MaxAmount
+			t.maxBids[i].UpdatedAt = now
+			return t.maxBids[i], nil
+		}
+	}
+
+	maxBid := pgstore.MaxBid{
+		ID:        uuid.New(),
+		ProductID: arg.ProductID,
+		BidderID:  arg.BidderID,
+		MaxAmount: arg.MaxAmount,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	t.maxBids = append(t.maxBids, maxBid)
+
+	return maxBid, nil
+}
+
+func (q *queries) ListMaxBidsByProductId(ctx context.Context, productID uuid.UUID) ([]pgstore.MaxBid, error) {
+	defer q.lock()()
+
+	var maxBids []pgstore.MaxBid
+	for _, maxBid := range q.tables().maxBids {
+		if maxBid.ProductID == productID {
+			maxBids = append(maxBids, maxBid)
+		}
+	}
+
+	slices.SortFunc(maxBids, func(a, b pgstore.MaxBid) int {
+		if a.MaxAmount != b.MaxAmount {
+			return cmp.Compare(b.MaxAmount, a.MaxAmount)
+		}
+		if c := a.UpdatedAt.Compare(b.UpdatedAt); c != 0 {
+			return c
+		}
+		return compareUUID(a.ID, b.ID)
+	})
+
+	return maxBids, nil
+}
@@ -0,0 +1,186 @@
+// Package memstore is an in-memory pgstore.Store for tests. It mirrors the
+// Postgres schema closely enough for the services to behave the same: unique
+// and foreign key violations are reported as *pgconn.PgError with the same
+// codes, missing rows as pgx.ErrNoRows, rows come back in the same order and
+// now() is the start time of the transaction, with microsecond precision.
+// Unlike Postgres, now() can follow a clock.Fake.
+//
+// Transactions are serializable: a transaction holds the whole store until it
+// commits or rolls back, which also stands in for row locks.
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	uniqueViolation         = "23505"
+	foreignKeyViolation     = "23503"
+	stringDataRightTruncate = "22001"
+)
+
+// Store is an in-memory database. The zero value is not usable; create one
+// with New.
+type Store struct {
+	queries
+
+	mu            sync.Mutex
+	clock         clock.Clock
+	data          tables
+	advisoryLocks map[int64]bool
+	notifications []pgstore.NotifyParams
+}
+
+type tables struct {
+	users          []pgstore.User
+	products       []pgstore.Product
+	bids           []pgstore.Bid
+	auctionBidders []pgstore.AuctionBidder
+	maxBids        []pgstore.MaxBid
+	auctionResults []pgstore.AuctionResult
+
+	// bidSeq is the last seq given to a bid.
+	bidSeq int64
+}
+
+func (t tables) clone() tables {
+	return tables{
+		users:          slices.Clone(t.users),
+		products:       slices.Clone(t.products),
+		bids:           slices.Clone(t.bids),
+		auctionBidders: slices.Clone(t.auctionBidders),
+		maxBids:        slices.Clone(t.maxBids),
+		auctionResults: slices.Clone(t.auctionResults),
+		bidSeq:         t.bidSeq,
+	}
+}
+
+var _ pgstore.Store = (*Store)(nil)
+
+func New() *Store {
+	return NewWithClock(clock.Real())
+}
+
+// NewWithClock creates a store whose now() is read from clk.
+func NewWithClock(clk clock.Clock) *Store {
+	s := &Store{clock: clk, advisoryLocks: make(map[int64]bool)}
+	s.queries = queries{store: s}
+
+	return s
+}
+
+// Begin starts a transaction, waiting for the one in progress to finish.
+func (s *Store) Begin(ctx context.Context) (pgstore.Tx, error) {
+	s.mu.Lock()
+
+	return &tx{
+		queries:  queries{store: s, inTx: true, txStart: s.now()},
+		snapshot: s.data.clone(),
+	}, nil
+}
+
+// Notifications returns the payloads sent with Notify so far.
+func (s *Store) Notifications() []pgstore.NotifyParams {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.notifications)
+}
+
+type tx struct {
+	queries
+	snapshot tables
+	done     bool
+}
+
+func (t *tx) Commit(ctx context.Context) error {
+	if t.done {
+		return pgx.ErrTxClosed
+	}
+
+	t.done = true
+	t.store.mu.Unlock()
+	return nil
+}
+
+func (t *tx) Rollback(ctx context.Context) error {
+	if t.done {
+		return nil
+	}
+
+	t.done = true
+	t.store.data = t.snapshot
+	t.store.mu.Unlock()
+	return nil
+}
+
+// queries implements pgstore.Querier. Outside of a transaction each call
+// holds the store for its own duration.
+type queries struct {
+	store   *Store
+	inTx    bool
+	txStart time.Time
+}
+
+func (q *queries) lock() func() {
+	if q.inTx {
+		return func() {}
+	}
+
+	q.store.mu.Lock()
+	return q.store.mu.Unlock
+}
+
+// now is what Postgres' now() returns to the query.
+func (q *queries) now() time.Time {
+	if q.inTx {
+		return q.txStart
+	}
+
+	return q.store.now()
+}
+
+func (s *Store) now() time.Time {
+	return s.clock.Now().Round(0).Truncate(time.Microsecond)
+}
+
+// timestamptz rounds t to the microsecond precision of a TIMESTAMPTZ column.
+func timestamptz(t time.Time) time.Time {
+	return t.Round(0).Round(time.Microsecond)
+}
+
+func (q *queries) tables() *tables {
+	return &q.store.data
+}
+
+func violation(code, constraint, message string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           code,
+		Message:        message,
+		ConstraintName: constraint,
+	}
+}
+
+func (q *queries) userExists(id uuid.UUID) bool {
+	return slices.ContainsFunc(q.tables().users, func(u pgstore.User) bool { return u.ID == id })
+}
+
+func (q *queries) productIndex(id uuid.UUID) int {
+	return slices.IndexFunc(q.tables().products, func(p pgstore.Product) bool { return p.ID == id })
+}
+
+// compareUUID orders UUIDs like Postgres does, byte by byte.
+func compareUUID(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
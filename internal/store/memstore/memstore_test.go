@@ -0,0 +1,76 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestRollbackDiscardsWrites(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+
+	tx, err := store.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	if _, err := tx.CreateUser(ctx, pgstore.CreateUserParams{UserName: "ana", Email: "ana@gobid.test"}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("failed to roll back: %v", err)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("expected a second rollback to be a no-op, got %v", err)
+	}
+
+	if _, err := store.GetUserByEmail(ctx, "ana@gobid.test"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected the user to be rolled back, got %v", err)
+	}
+}
+
+func TestHighestBidPrefersEarliestOnTies(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+
+	seller, _ := store.CreateUser(ctx, pgstore.CreateUserParams{UserName: "seller", Email: "seller@gobid.test"})
+	first, _ := store.CreateUser(ctx, pgstore.CreateUserParams{UserName: "first", Email: "first@gobid.test"})
+	second, _ := store.CreateUser(ctx, pgstore.CreateUserParams{UserName: "second", Email: "second@gobid.test"})
+	productId, err := store.CreateProduct(ctx, pgstore.CreateProductParams{
+		SellerID:    seller,
+		BasePrice:   100,
+		AuctionType: pgstore.AuctionTypeEnglish,
+		AuctionEnd:  time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	for _, bid := range []pgstore.CreateBidParams{
+		{ProductID: productId, BidderID: first, BidAmount: 200},
+		{ProductID: productId, BidderID: second, BidAmount: 200},
+		{ProductID: productId, BidderID: second, BidAmount: 150},
+	} {
+		if _, err := store.CreateBid(ctx, bid); err != nil {
+			t.Fatalf("failed to create bid: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	highest, err := store.GetHighestBidByProductId(ctx, productId)
+	if err != nil {
+		t.Fatalf("failed to get highest bid: %v", err)
+	}
+	if highest.BidderID != first {
+		t.Errorf("expected the earliest of the tied bids to win")
+	}
+
+	stats, _ := store.GetBidStatsByProductId(ctx, productId)
+	if stats.HighBid != 200 || stats.BidCount != 3 {
+		t.Errorf("expected a high bid of 200 over 3 bids, got %+v", stats)
+	}
+}
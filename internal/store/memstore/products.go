@@ -0,0 +1,323 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (q *queries) CreateProduct(ctx context.Context, arg pgstore.CreateProductParams) (uuid.UUID, error) {
+	defer q.lock()()
+
+	if !q.userExists(arg.SellerID) {
+		return uuid.UUID{}, violation(foreignKeyViolation, "products_seller_id_fkey", `insert or update on table "products" violates foreign key constraint "products_seller_id_fkey"`)
+	}
+
+	now := q.now()
+	product := pgstore.Product{
+		ID:                       uuid.New(),
+		SellerID:                 arg.SellerID,
+		ProductName:              arg.ProductName,
+		Description:              arg.Description,
+		BasePrice:                arg.BasePrice,
+		AuctionEnd:               timestamptz(arg.AuctionEnd),
+		CreatedAt:                now,
+		UpdatedAt:                now,
+		Currency:                 arg.Currency,
+		BidIncrements:            arg.BidIncrements,
+		ReservePrice:             arg.ReservePrice,
+		BuyNowPrice:              arg.BuyNowPrice,
+		AuctionType:              arg.AuctionType,
+		PriceDropAmount:          arg.PriceDropAmount,
+		PriceDropIntervalSeconds: arg.PriceDropIntervalSeconds,
+		AuctionStart:             timestamptz(arg.AuctionStart),
+		FloorPrice:               arg.FloorPrice,
+	}
+	q.tables().products = append(q.tables().products, product)
+
+	return product.ID, nil
+}
+
+func (q *queries) GetProductById(ctx context.Context, id uuid.UUID) (pgstore.Product, error) {
+	defer q.lock()()
+
+	return q.product(id)
+}
+
+// GetProductByIdForUpdate needs no row lock: a transaction already holds the
+// whole store.
+func (q *queries) GetProductByIdForUpdate(ctx context.Context, id uuid.UUID) (pgstore.Product, error) {
+	defer q.lock()()
+
+	return q.product(id)
+}
+
+func (q *queries) GetProductListingById(ctx context.Context, id uuid.UUID) (pgstore.GetProductListingByIdRow, error) {
+	defer q.lock()()
+
+	product, err := q.product(id)
+	if err != nil {
+		return pgstore.GetProductListingByIdRow{}, err
+	}
+
+	stats := q.bidStats(id)
+	return pgstore.GetProductListingByIdRow{
+		Product:  product,
+		HighBid:  stats.HighBid,
+		BidCount: stats.BidCount,
+	}, nil
+}
+
+func (q *queries) ListActiveProducts(ctx context.Context, now time.Time) ([]pgstore.Product, error) {
+	defer q.lock()()
+
+	products := q.filterProducts(func(p pgstore.Product) bool {
+		return !p.IsSold && !p.AuctionStart.After(now) && p.AuctionEnd.After(now)
+	})
+	slices.SortStableFunc(products, func(a, b pgstore.Product) int {
+		return a.AuctionEnd.Compare(b.AuctionEnd)
+	})
+
+	return products, nil
+}
+
+func (q *queries) ListScheduledProducts(ctx context.Context, now time.Time) ([]pgstore.Product, error) {
+	defer q.lock()()
+
+	products := q.filterProducts(func(p pgstore.Product) bool {
+		return !p.IsSold && p.AuctionStart.After(now)
+	})
+	slices.SortStableFunc(products, func(a, b pgstore.Product) int {
+		return a.AuctionStart.Compare(b.AuctionStart)
+	})
+
+	return products, nil
+}
+
+func (q *queries) ListUnsettledEndedProducts(ctx context.Context, now time.Time) ([]pgstore.Product, error) {
+	defer q.lock()()
+
+	products := q.filterProducts(func(p pgstore.Product) bool {
+		if p.AuctionEnd.After(now) {
+			return false
+		}
+		_, settled := q.auctionResult(p.ID)
+		return !settled
+	})
+	slices.SortStableFunc(products, func(a, b pgstore.Product) int {
+		return a.AuctionEnd.Compare(b.AuctionEnd)
+	})
+
+	return products, nil
+}
+
+func (q *queries) MarkProductAsSold(ctx context.Context, id uuid.UUID) error {
+	defer q.lock()()
+
+	if i := q.productIndex(id); i >= 0 {
+		product := &q.tables().products[i]
+		product.IsSold = true
+		product.UpdatedAt = q.now()
+	}
+
+	return nil
+}
+
+func (q *queries) UpdateProductAuctionEnd(ctx context.Context, arg pgstore.UpdateProductAuctionEndParams) error {
+	defer q.lock()()
+
+	if i := q.productIndex(arg.ID); i >= 0 {
+		product := &q.tables().products[i]
+		product.AuctionEnd = timestamptz(arg.AuctionEnd)
+		product.UpdatedAt = q.now()
+	}
+
+	return nil
+}
+
+func (q *queries) ListProducts(ctx context.Context, arg pgstore.ListProductsParams) ([]pgstore.ListProductsRow, error) {
+	defer q.lock()()
+
+	var search *searchQuery
+	if arg.Search.Valid {
+		search = parseSearch(arg.Search.String)
+	}
+
+	var rows []pgstore.ListProductsRow
+	for _, product := range q.tables().products {
+		stats := q.bidStats(product.ID)
+		row := pgstore.ListProductsRow{
+			Product:      product,
+			HighBid:      stats.HighBid,
+			BidCount:     stats.BidCount,
+			CurrentPrice: currentPrice(product, stats, arg.Now),
+		}
+		row.SortKey = sortKey(arg.SortBy, row)
+
+		if arg.Status.Valid && !hasStatus(product, arg.Status.String, arg.Now) {
+			continue
+		}
+		if arg.SellerID.Valid && product.SellerID != arg.SellerID.Bytes {
+			continue
+		}
+		if arg.MinPrice.Valid && row.CurrentPrice < arg.MinPrice.Int64 {
+			continue
+		}
+		if arg.MaxPrice.Valid && row.CurrentPrice > arg.MaxPrice.Int64 {
+			continue
+		}
+		if arg.EndingBefore.Valid && !product.AuctionEnd.Before(arg.EndingBefore.Time) {
+			continue
+		}
+		if search != nil && !search.matches(product.ProductName+" "+product.Description) {
+			continue
+		}
+		if arg.AfterSortKey.Valid {
+			c := cmp.Compare(row.SortKey, arg.AfterSortKey.Int64)
+			if c < 0 || c == 0 && compareUUID(product.ID, arg.AfterID.Bytes) <= 0 {
+				continue
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	slices.SortFunc(rows, func(a, b pgstore.ListProductsRow) int {
+		if c := cmp.Compare(a.SortKey, b.SortKey); c != 0 {
+			return c
+		}
+		return compareUUID(a.Product.ID, b.Product.ID)
+	})
+	if len(rows) > int(arg.PageSize) {
+		rows = rows[:arg.PageSize]
+	}
+
+	return rows, nil
+}
+
+func (q *queries) product(id uuid.UUID) (pgstore.Product, error) {
+	i := q.productIndex(id)
+	if i < 0 {
+		return pgstore.Product{}, pgx.ErrNoRows
+	}
+
+	return q.tables().products[i], nil
+}
+
+func (q *queries) filterProducts(keep func(pgstore.Product) bool) []pgstore.Product {
+	var products []pgstore.Product
+	for _, product := range q.tables().products {
+		if keep(product) {
+			products = append(products, product)
+		}
+	}
+
+	return products
+}
+
+// currentPrice follows the current_price CASE of ListProducts.
+func currentPrice(p pgstore.Product, stats pgstore.GetBidStatsByProductIdRow, now time.Time) int64 {
+	switch {
+	case p.AuctionType == pgstore.AuctionTypeEnglish:
+		return max(p.BasePrice, stats.HighBid)
+	case p.AuctionType == pgstore.AuctionTypeDutch && stats.BidCount > 0:
+		return stats.HighBid
+	case p.AuctionType == pgstore.AuctionTypeDutch:
+		elapsed := max(now.Sub(p.AuctionStart), 0)
+		drops := min(
+			int64(elapsed/time.Second)/int64(p.PriceDropIntervalSeconds.Int32),
+			(p.BasePrice-p.FloorPrice.Int64+p.PriceDropAmount.Int64-1)/p.PriceDropAmount.Int64,
+		)
+		return max(p.BasePrice-drops*p.PriceDropAmount.Int64, p.FloorPrice.Int64)
+	default:
+		return p.BasePrice
+	}
+}
+
+func sortKey(sortBy string, row pgstore.ListProductsRow) int64 {
+	switch sortBy {
+	case "newest":
+		return -row.Product.CreatedAt.UnixMicro()
+	case "price_asc":
+		return row.CurrentPrice
+	case "price_desc":
+		return -row.CurrentPrice
+	default:
+		return row.Product.AuctionEnd.UnixMicro()
+	}
+}
+
+func hasStatus(p pgstore.Product, status string, now time.Time) bool {
+	switch status {
+	case "scheduled":
+		return !p.IsSold && p.AuctionStart.After(now)
+	case "active":
+		return !p.IsSold && !p.AuctionStart.After(now) && p.AuctionEnd.After(now)
+	case "ended":
+		return !p.IsSold && !p.AuctionEnd.After(now)
+	case "sold":
+		return p.IsSold
+	default:
+		return false
+	}
+}
+
+// searchQuery approximates websearch_to_tsquery('english', ...): every term
+// must appear and every "-term" must not, after a naive plural stemming.
+// Quoted phrases and "or" are not supported.
+type searchQuery struct {
+	include []string
+	exclude []string
+}
+
+func parseSearch(text string) *searchQuery {
+	var query searchQuery
+	for _, field := range strings.Fields(text) {
+		negated := strings.HasPrefix(field, "-")
+		for _, word := range searchWords(field) {
+			if negated {
+				query.exclude = append(query.exclude, word)
+			} else {
+				query.include = append(query.include, word)
+			}
+		}
+	}
+
+	return &query
+}
+
+func (s *searchQuery) matches(document string) bool {
+	words := searchWords(document)
+	for _, word := range s.include {
+		if !slices.Contains(words, word) {
+			return false
+		}
+	}
+	for _, word := range s.exclude {
+		if slices.Contains(words, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func searchWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, field := range fields {
+		if len(field) > 3 && strings.HasSuffix(field, "s") && !strings.HasSuffix(field, "ss") {
+			fields[i] = strings.TrimSuffix(field, "s")
+		}
+	}
+
+	return fields
+}
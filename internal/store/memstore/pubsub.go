@@ -0,0 +1,37 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+)
+
+func (q *queries) AdvisoryUnlock(ctx context.Context, key int64) (bool, error) {
+	defer q.lock()()
+
+	if !q.store.advisoryLocks[key] {
+		return false, nil
+	}
+
+	delete(q.store.advisoryLocks, key)
+	return true, nil
+}
+
+// Notify records the notification; nothing listens to an in-memory store.
+func (q *queries) Notify(ctx context.Context, arg pgstore.NotifyParams) error {
+	defer q.lock()()
+
+	q.store.notifications = append(q.store.notifications, arg)
+	return nil
+}
+
+func (q *queries) TryAdvisoryLock(ctx context.Context, key int64) (bool, error) {
+	defer q.lock()()
+
+	if q.store.advisoryLocks[key] {
+		return false, nil
+	}
+
+	q.store.advisoryLocks[key] = true
+	return true, nil
+}
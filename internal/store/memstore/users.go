@@ -0,0 +1,66 @@
+package memstore
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (q *queries) CreateUser(ctx context.Context, arg pgstore.CreateUserParams) (uuid.UUID, error) {
+	defer q.lock()()
+
+	if utf8.RuneCountInString(arg.UserName) > 50 {
+		return uuid.UUID{}, violation(stringDataRightTruncate, "", "value too long for type character varying(50)")
+	}
+
+	t := q.tables()
+	for _, user := range t.users {
+		if user.UserName == arg.UserName {
+			return uuid.UUID{}, violation(uniqueViolation, "users_user_name_key", `duplicate key value violates unique constraint "users_user_name_key"`)
+		}
+		if user.Email == arg.Email {
+			return uuid.UUID{}, violation(uniqueViolation, "users_email_key", `duplicate key value violates unique constraint "users_email_key"`)
+		}
+	}
+
+	now := q.now()
+	user := pgstore.User{
+		ID:           uuid.New(),
+		UserName:     arg.UserName,
+		Email:        arg.Email,
+		PasswordHash: arg.PasswordHash,
+		Bio:          arg.Bio,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	t.users = append(t.users, user)
+
+	return user.ID, nil
+}
+
+func (q *queries) GetUserByID(ctx context.Context, id uuid.UUID) (pgstore.User, error) {
+	defer q.lock()()
+
+	for _, user := range q.tables().users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+
+	return pgstore.User{}, pgx.ErrNoRows
+}
+
+func (q *queries) GetUserByEmail(ctx context.Context, email string) (pgstore.User, error) {
+	defer q.lock()()
+
+	for _, user := range q.tables().users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return pgstore.User{}, pgx.ErrNoRows
+}
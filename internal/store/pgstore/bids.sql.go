@@ -7,22 +7,33 @@ package pgstore
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createBid = `-- name: CreateBid :one
+WITH bidder AS (
+  INSERT INTO auction_bidders (product_id, bidder_id, bidder_number)
+  SELECT $1, $2, COALESCE(MAX(bidder_number), 0) + 1
+  FROM auction_bidders
+  WHERE product_id = $1
+  ON CONFLICT (product_id, bidder_id) DO NOTHING
+)
 INSERT INTO bids (product_id, bidder_id, bid_amount)
 VALUES ($1, $2, $3)
-RETURNING id, product_id, bidder_id, bid_amount, created_at
+RETURNING id, product_id, bidder_id, bid_amount, created_at, seq
 `
 
 type CreateBidParams struct {
 	ProductID uuid.UUID `json:"product_id"`
 	BidderID  uuid.UUID `json:"bidder_id"`
-	BidAmount float64   `json:"bid_amount"`
+	BidAmount int64     `json:"bid_amount"`
 }
 
+// The bidder of a product's first bid gets the next bidder number of the
+// product, which later bids and sealed bid revisions keep.
 func (q *Queries) CreateBid(ctx context.Context, arg CreateBidParams) (Bid, error) {
 	row := q.db.QueryRow(ctx, createBid, arg.ProductID, arg.BidderID, arg.BidAmount)
 	var i Bid
@@ -32,12 +43,13 @@ func (q *Queries) CreateBid(ctx context.Context, arg CreateBidParams) (Bid, erro
 		&i.BidderID,
 		&i.BidAmount,
 		&i.CreatedAt,
+		&i.Seq,
 	)
 	return i, err
 }
 
 const getBidsByProductId = `-- name: GetBidsByProductId :many
-SELECT id, product_id, bidder_id, bid_amount, created_at FROM bids
+SELECT id, product_id, bidder_id, bid_amount, created_at, seq FROM bids
 WHERE product_id = $1
 ORDER BY bid_amount DESC
 `
@@ -57,6 +69,7 @@ func (q *Queries) GetBidsByProductId(ctx context.Context, productID uuid.UUID) (
 			&i.BidderID,
 			&i.BidAmount,
 			&i.CreatedAt,
+			&i.Seq,
 		); err != nil {
 			return nil, err
 		}
@@ -69,9 +82,9 @@ func (q *Queries) GetBidsByProductId(ctx context.Context, productID uuid.UUID) (
 }
 
 const getHighestBidByProductId = `-- name: GetHighestBidByProductId :one
-SELECT id, product_id, bidder_id, bid_amount, created_at FROM bids
+SELECT id, product_id, bidder_id, bid_amount, created_at, seq FROM bids
 WHERE product_id = $1
-ORDER BY bid_amount DESC
+ORDER BY bid_amount DESC, created_at ASC
 LIMIT 1
 `
 
@@ -84,6 +97,134 @@ func (q *Queries) GetHighestBidByProductId(ctx context.Context, productID uuid.U
 		&i.BidderID,
 		&i.BidAmount,
 		&i.CreatedAt,
+		&i.Seq,
 	)
 	return i, err
 }
+
+const listTopBidsByProductId = `-- name: ListTopBidsByProductId :many
+SELECT id, product_id, bidder_id, bid_amount, created_at, seq FROM bids
+WHERE product_id = $1
+ORDER BY bid_amount DESC, created_at ASC
+LIMIT $2
+`
+
+type ListTopBidsByProductIdParams struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Limit     int32     `json:"limit"`
+}
+
+func (q *Queries) ListTopBidsByProductId(ctx context.Context, arg ListTopBidsByProductIdParams) ([]Bid, error) {
+	rows, err := q.db.Query(ctx, listTopBidsByProductId, arg.ProductID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bid
+	for rows.Next() {
+		var i Bid
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.BidderID,
+			&i.BidAmount,
+			&i.CreatedAt,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteBidsByProductIdAndBidderId = `-- name: DeleteBidsByProductIdAndBidderId :exec
+DELETE FROM bids
+WHERE product_id = $1 AND bidder_id = $2
+`
+
+type DeleteBidsByProductIdAndBidderIdParams struct {
+	ProductID uuid.UUID `json:"product_id"`
+	BidderID  uuid.UUID `json:"bidder_id"`
+}
+
+func (q *Queries) DeleteBidsByProductIdAndBidderId(ctx context.Context, arg DeleteBidsByProductIdAndBidderIdParams) error {
+	_, err := q.db.Exec(ctx, deleteBidsByProductIdAndBidderId, arg.ProductID, arg.BidderID)
+	return err
+}
+
+const listBidHistoryByProductId = `-- name: ListBidHistoryByProductId :many
+SELECT bids.id, bids.bidder_id, bids.bid_amount, bids.created_at, bids.seq, users.user_name, auction_bidders.bidder_number
+FROM bids
+JOIN users ON users.id = bids.bidder_id
+JOIN auction_bidders ON auction_bidders.product_id = bids.product_id AND auction_bidders.bidder_id = bids.bidder_id
+WHERE bids.product_id = $1
+  AND ($2::bigint IS NULL OR bids.seq < $2)
+ORDER BY bids.seq DESC
+LIMIT $3
+`
+
+type ListBidHistoryByProductIdParams struct {
+	ProductID uuid.UUID   `json:"product_id"`
+	BeforeSeq pgtype.Int8 `json:"before_seq"`
+	PageSize  int32       `json:"page_size"`
+}
+
+type ListBidHistoryByProductIdRow struct {
+	ID           uuid.UUID `json:"id"`
+	BidderID     uuid.UUID `json:"bidder_id"`
+	BidAmount    int64     `json:"bid_amount"`
+	CreatedAt    time.Time `json:"created_at"`
+	Seq          int64     `json:"seq"`
+	UserName     string    `json:"user_name"`
+	BidderNumber int64     `json:"bidder_number"`
+}
+
+func (q *Queries) ListBidHistoryByProductId(ctx context.Context, arg ListBidHistoryByProductIdParams) ([]ListBidHistoryByProductIdRow, error) {
+	rows, err := q.db.Query(ctx, listBidHistoryByProductId, arg.ProductID, arg.BeforeSeq, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBidHistoryByProductIdRow
+	for rows.Next() {
+		var i ListBidHistoryByProductIdRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BidderID,
+			&i.BidAmount,
+			&i.CreatedAt,
+			&i.Seq,
+			&i.UserName,
+			&i.BidderNumber,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBidStatsByProductId = `-- name: GetBidStatsByProductId :one
+SELECT COALESCE(MAX(bid_amount), 0)::bigint AS high_bid, COUNT(*) AS bid_count
+FROM bids
+WHERE product_id = $1
+`
+
+type GetBidStatsByProductIdRow struct {
+	HighBid  int64 `json:"high_bid"`
+	BidCount int64 `json:"bid_count"`
+}
+
+func (q *Queries) GetBidStatsByProductId(ctx context.Context, productID uuid.UUID) (GetBidStatsByProductIdRow, error) {
+	row := q.db.QueryRow(ctx, getBidStatsByProductId, productID)
+	var i GetBidStatsByProductIdRow
+	err := row.Scan(&i.HighBid, &i.BidCount)
+	return i, err
+}
@@ -0,0 +1,17 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS auction_results (
+  product_id UUID PRIMARY KEY REFERENCES products (id),
+
+  winner_id UUID REFERENCES users (id),
+  winning_bid_id UUID REFERENCES bids (id),
+  final_price FLOAT,
+  status TEXT NOT NULL,
+
+  settled_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS auction_results;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,32 @@
+-- Write your migrate up statements here
+-- Prices are stored as integer amounts in the currency's minor unit (cents).
+ALTER TABLE products
+  ALTER COLUMN base_price TYPE BIGINT USING round(base_price * 100)::BIGINT,
+  ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+ALTER TABLE bids
+  ALTER COLUMN bid_amount TYPE BIGINT USING round(bid_amount * 100)::BIGINT;
+
+ALTER TABLE auction_results
+  ALTER COLUMN final_price TYPE BIGINT USING round(final_price * 100)::BIGINT,
+  ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+UPDATE auction_results
+SET currency = products.currency
+FROM products
+WHERE products.id = auction_results.product_id;
+---- create above / drop below ----
+
+ALTER TABLE auction_results
+  DROP COLUMN IF EXISTS currency,
+  ALTER COLUMN final_price TYPE FLOAT USING final_price / 100.0;
+
+ALTER TABLE bids
+  ALTER COLUMN bid_amount TYPE FLOAT USING bid_amount / 100.0;
+
+ALTER TABLE products
+  DROP COLUMN IF EXISTS currency,
+  ALTER COLUMN base_price TYPE FLOAT USING base_price / 100.0;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,11 @@
+-- Write your migrate up statements here
+-- Increment schedules are a JSON list of bands: [{"from": 0, "increment": 100}].
+-- Existing products keep accepting raises of a single minor unit.
+ALTER TABLE products
+  ADD COLUMN bid_increments JSONB NOT NULL DEFAULT '[{"from": 0, "increment": 1}]';
+---- create above / drop below ----
+
+ALTER TABLE products DROP COLUMN IF EXISTS bid_increments;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,8 @@
+-- Write your migrate up statements here
+ALTER TABLE products ADD COLUMN buy_now_price BIGINT;
+---- create above / drop below ----
+
+ALTER TABLE products DROP COLUMN IF EXISTS buy_now_price;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
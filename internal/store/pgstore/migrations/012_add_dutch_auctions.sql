@@ -0,0 +1,21 @@
+-- Write your migrate up statements here
+ALTER TYPE auction_type ADD VALUE 'dutch';
+
+ALTER TABLE products ADD COLUMN price_drop_amount BIGINT;
+ALTER TABLE products ADD COLUMN price_drop_interval_seconds INTEGER;
+---- create above / drop below ----
+
+ALTER TABLE products DROP COLUMN IF EXISTS price_drop_interval_seconds;
+ALTER TABLE products DROP COLUMN IF EXISTS price_drop_amount;
+
+UPDATE products SET auction_type = 'english' WHERE auction_type = 'dutch';
+
+ALTER TABLE products ALTER COLUMN auction_type DROP DEFAULT;
+ALTER TYPE auction_type RENAME TO auction_type_old;
+CREATE TYPE auction_type AS ENUM ('english', 'sealed_first_price', 'sealed_second_price');
+ALTER TABLE products ALTER COLUMN auction_type TYPE auction_type USING auction_type::text::auction_type;
+ALTER TABLE products ALTER COLUMN auction_type SET DEFAULT 'english';
+DROP TYPE auction_type_old;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
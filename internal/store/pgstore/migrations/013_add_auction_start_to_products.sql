@@ -0,0 +1,10 @@
+-- Write your migrate up statements here
+ALTER TABLE products ADD COLUMN auction_start TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE products SET auction_start = created_at;
+---- create above / drop below ----
+
+ALTER TABLE products DROP COLUMN IF EXISTS auction_start;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
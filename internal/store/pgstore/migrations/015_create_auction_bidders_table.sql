@@ -0,0 +1,26 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS auction_bidders (
+  product_id UUID NOT NULL REFERENCES products (id),
+  bidder_id UUID NOT NULL REFERENCES users (id),
+  bidder_number BIGINT NOT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  PRIMARY KEY (product_id, bidder_id),
+  UNIQUE (product_id, bidder_number)
+);
+
+INSERT INTO auction_bidders (product_id, bidder_id, bidder_number, created_at)
+SELECT
+  product_id,
+  bidder_id,
+  ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY MIN(created_at), bidder_id),
+  MIN(created_at)
+FROM bids
+GROUP BY product_id, bidder_id;
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS auction_bidders;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
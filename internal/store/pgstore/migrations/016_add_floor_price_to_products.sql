@@ -0,0 +1,13 @@
+-- Write your migrate up statements here
+ALTER TABLE products ADD COLUMN floor_price BIGINT;
+
+-- Existing dutch auctions keep stopping at their last positive step.
+UPDATE products
+SET floor_price = base_price - ((base_price - 1) / price_drop_amount) * price_drop_amount
+WHERE auction_type = 'dutch';
+---- create above / drop below ----
+
+ALTER TABLE products DROP COLUMN IF EXISTS floor_price;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
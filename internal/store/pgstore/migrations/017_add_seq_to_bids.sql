@@ -0,0 +1,27 @@
+-- Write your migrate up statements here
+-- Bids placed in one transaction share created_at, so the history is ordered
+-- by seq instead. Bids on a product are serialized by the product's row
+-- lock, so seq follows the order they were placed in.
+CREATE SEQUENCE IF NOT EXISTS bids_seq_seq;
+
+ALTER TABLE bids ADD COLUMN seq BIGINT;
+
+UPDATE bids
+SET seq = ordered.seq
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS seq FROM bids) AS ordered
+WHERE bids.id = ordered.id;
+
+SELECT setval('bids_seq_seq', COALESCE(MAX(seq), 0) + 1, false) FROM bids;
+
+ALTER TABLE bids
+  ALTER COLUMN seq SET DEFAULT nextval('bids_seq_seq'),
+  ALTER COLUMN seq SET NOT NULL,
+  ADD CONSTRAINT bids_seq_key UNIQUE (seq);
+
+ALTER SEQUENCE bids_seq_seq OWNED BY bids.seq;
+---- create above / drop below ----
+
+ALTER TABLE bids DROP COLUMN IF EXISTS seq;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -5,29 +5,124 @@
 package pgstore
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"time"
 
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuctionType string
+
+const (
+	AuctionTypeEnglish           AuctionType = "english"
+	AuctionTypeSealedFirstPrice  AuctionType = "sealed_first_price"
+	AuctionTypeSealedSecondPrice AuctionType = "sealed_second_price"
+	AuctionTypeDutch             AuctionType = "dutch"
+)
+
+func (e *AuctionType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AuctionType(s)
+	case string:
+		*e = AuctionType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AuctionType: %T", src)
+	}
+	return nil
+}
+
+type NullAuctionType struct {
+	AuctionType AuctionType `json:"auction_type"`
+	Valid       bool        `json:"valid"` // Valid is true if AuctionType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAuctionType) Scan(value interface{}) error {
+	if value == nil {
+		ns.AuctionType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AuctionType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAuctionType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AuctionType), nil
+}
+
+func (e AuctionType) Valid() bool {
+	switch e {
+	case AuctionTypeEnglish,
+		AuctionTypeSealedFirstPrice,
+		AuctionTypeSealedSecondPrice,
+		AuctionTypeDutch:
+		return true
+	}
+	return false
+}
+
+type AuctionBidder struct {
+	ProductID    uuid.UUID `json:"product_id"`
+	BidderID     uuid.UUID `json:"bidder_id"`
+	BidderNumber int64     `json:"bidder_number"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AuctionResult struct {
+	ProductID    uuid.UUID   `json:"product_id"`
+	WinnerID     pgtype.UUID `json:"winner_id"`
+	WinningBidID pgtype.UUID `json:"winning_bid_id"`
+	FinalPrice   pgtype.Int8 `json:"final_price"`
+	Status       string      `json:"status"`
+	SettledAt    time.Time   `json:"settled_at"`
+	Currency     string      `json:"currency"`
+}
+
 type Bid struct {
 	ID        uuid.UUID `json:"id"`
 	ProductID uuid.UUID `json:"product_id"`
 	BidderID  uuid.UUID `json:"bidder_id"`
-	BidAmount float64   `json:"bid_amount"`
+	BidAmount int64     `json:"bid_amount"`
+	CreatedAt time.Time `json:"created_at"`
+	Seq       int64     `json:"seq"`
+}
+
+type MaxBid struct {
+	ID        uuid.UUID `json:"id"`
+	ProductID uuid.UUID `json:"product_id"`
+	BidderID  uuid.UUID `json:"bidder_id"`
+	MaxAmount int64     `json:"max_amount"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Product struct {
-	ID          uuid.UUID `json:"id"`
-	SellerID    uuid.UUID `json:"seller_id"`
-	ProductName string    `json:"product_name"`
-	Description string    `json:"description"`
-	BasePrice   float64   `json:"base_price"`
-	AuctionEnd  time.Time `json:"auction_end"`
-	IsSold      bool      `json:"is_sold"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                       uuid.UUID               `json:"id"`
+	SellerID                 uuid.UUID               `json:"seller_id"`
+	ProductName              string                  `json:"product_name"`
+	Description              string                  `json:"description"`
+	BasePrice                int64                   `json:"base_price"`
+	AuctionEnd               time.Time               `json:"auction_end"`
+	IsSold                   bool                    `json:"is_sold"`
+	CreatedAt                time.Time               `json:"created_at"`
+	UpdatedAt                time.Time               `json:"updated_at"`
+	Currency                 string                  `json:"currency"`
+	BidIncrements            money.IncrementSchedule `json:"bid_increments"`
+	ReservePrice             pgtype.Int8             `json:"-"`
+	BuyNowPrice              pgtype.Int8             `json:"buy_now_price"`
+	AuctionType              AuctionType             `json:"auction_type"`
+	PriceDropAmount          pgtype.Int8             `json:"price_drop_amount"`
+	PriceDropIntervalSeconds pgtype.Int4             `json:"price_drop_interval_seconds"`
+	AuctionStart             time.Time               `json:"auction_start"`
+	FloorPrice               pgtype.Int8             `json:"floor_price"`
 }
 
 type Session struct {
@@ -9,21 +9,32 @@ import (
 	"context"
 	"time"
 
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createProduct = `-- name: CreateProduct :one
-INSERT INTO products (seller_id, product_name, description, base_price, auction_end)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO products (seller_id, product_name, description, base_price, currency, auction_end, bid_increments, reserve_price, buy_now_price, auction_type, price_drop_amount, price_drop_interval_seconds, auction_start, floor_price)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 RETURNING id
 `
 
 type CreateProductParams struct {
-	SellerID    uuid.UUID `json:"seller_id"`
-	ProductName string    `json:"product_name"`
-	Description string    `json:"description"`
-	BasePrice   float64   `json:"base_price"`
-	AuctionEnd  time.Time `json:"auction_end"`
+	SellerID                 uuid.UUID               `json:"seller_id"`
+	ProductName              string                  `json:"product_name"`
+	Description              string                  `json:"description"`
+	BasePrice                int64                   `json:"base_price"`
+	Currency                 string                  `json:"currency"`
+	AuctionEnd               time.Time               `json:"auction_end"`
+	BidIncrements            money.IncrementSchedule `json:"bid_increments"`
+	ReservePrice             pgtype.Int8             `json:"-"`
+	BuyNowPrice              pgtype.Int8             `json:"buy_now_price"`
+	AuctionType              AuctionType             `json:"auction_type"`
+	PriceDropAmount          pgtype.Int8             `json:"price_drop_amount"`
+	PriceDropIntervalSeconds pgtype.Int4             `json:"price_drop_interval_seconds"`
+	AuctionStart             time.Time               `json:"auction_start"`
+	FloorPrice               pgtype.Int8             `json:"floor_price"`
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (uuid.UUID, error) {
@@ -32,7 +43,16 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (u
 		arg.ProductName,
 		arg.Description,
 		arg.BasePrice,
+		arg.Currency,
 		arg.AuctionEnd,
+		arg.BidIncrements,
+		arg.ReservePrice,
+		arg.BuyNowPrice,
+		arg.AuctionType,
+		arg.PriceDropAmount,
+		arg.PriceDropIntervalSeconds,
+		arg.AuctionStart,
+		arg.FloorPrice,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
@@ -40,7 +60,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (u
 }
 
 const getProductById = `-- name: GetProductById :one
-SELECT id, seller_id, product_name, description, base_price, auction_end, is_sold, created_at, updated_at FROM products
+SELECT id, seller_id, product_name, description, base_price, auction_end, is_sold, created_at, updated_at, currency, bid_increments, reserve_price, buy_now_price, auction_type, price_drop_amount, price_drop_interval_seconds, auction_start, floor_price FROM products
 WHERE id = $1
 `
 
@@ -57,6 +77,392 @@ func (q *Queries) GetProductById(ctx context.Context, id uuid.UUID) (Product, er
 		&i.IsSold,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.BidIncrements,
+		&i.ReservePrice,
+		&i.BuyNowPrice,
+		&i.AuctionType,
+		&i.PriceDropAmount,
+		&i.PriceDropIntervalSeconds,
+		&i.AuctionStart,
+		&i.FloorPrice,
+	)
+	return i, err
+}
+
+const listActiveProducts = `-- name: ListActiveProducts :many
+SELECT id, seller_id, product_name, description, base_price, auction_end, is_sold, created_at, updated_at, currency, bid_increments, reserve_price, buy_now_price, auction_type, price_drop_amount, price_drop_interval_seconds, auction_start, floor_price FROM products
+WHERE is_sold = false AND auction_start <= $1 AND auction_end > $1
+ORDER BY auction_end
+`
+
+func (q *Queries) ListActiveProducts(ctx context.Context, now time.Time) ([]Product, error) {
+	rows, err := q.db.Query(ctx, listActiveProducts, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.ProductName,
+			&i.Description,
+			&i.BasePrice,
+			&i.AuctionEnd,
+			&i.IsSold,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+			&i.BidIncrements,
+			&i.ReservePrice,
+			&i.BuyNowPrice,
+			&i.AuctionType,
+			&i.PriceDropAmount,
+			&i.PriceDropIntervalSeconds,
+			&i.AuctionStart,
+			&i.FloorPrice,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledProducts = `-- name: ListScheduledProducts :many
+SELECT id, seller_id, product_name, description, base_price, auction_end, is_sold, created_at, updated_at, currency, bid_increments, reserve_price, buy_now_price, auction_type, price_drop_amount, price_drop_interval_seconds, auction_start, floor_price FROM products
+WHERE is_sold = false AND auction_start > $1
+ORDER BY auction_start
+`
+
+func (q *Queries) ListScheduledProducts(ctx context.Context, now time.Time) ([]Product, error) {
+	rows, err := q.db.Query(ctx, listScheduledProducts, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.ProductName,
+			&i.Description,
+			&i.BasePrice,
+			&i.AuctionEnd,
+			&i.IsSold,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+			&i.BidIncrements,
+			&i.ReservePrice,
+			&i.BuyNowPrice,
+			&i.AuctionType,
+			&i.PriceDropAmount,
+			&i.PriceDropIntervalSeconds,
+			&i.AuctionStart,
+			&i.FloorPrice,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProductByIdForUpdate = `-- name: GetProductByIdForUpdate :one
+SELECT id, seller_id, product_name, description, base_price, auction_end, is_sold, created_at, updated_at, currency, bid_increments, reserve_price, buy_now_price, auction_type, price_drop_amount, price_drop_interval_seconds, auction_start, floor_price FROM products
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetProductByIdForUpdate(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, getProductByIdForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.ProductName,
+		&i.Description,
+		&i.BasePrice,
+		&i.AuctionEnd,
+		&i.IsSold,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Currency,
+		&i.BidIncrements,
+		&i.ReservePrice,
+		&i.BuyNowPrice,
+		&i.AuctionType,
+		&i.PriceDropAmount,
+		&i.PriceDropIntervalSeconds,
+		&i.AuctionStart,
+		&i.FloorPrice,
+	)
+	return i, err
+}
+
+const markProductAsSold = `-- name: MarkProductAsSold :exec
+UPDATE products
+SET is_sold = true, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkProductAsSold(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markProductAsSold, id)
+	return err
+}
+
+const listUnsettledEndedProducts = `-- name: ListUnsettledEndedProducts :many
+SELECT id, seller_id, product_name, description, base_price, auction_end, is_sold, created_at, updated_at, currency, bid_increments, reserve_price, buy_now_price, auction_type, price_drop_amount, price_drop_interval_seconds, auction_start, floor_price FROM products
+WHERE auction_end <= $1
+  AND NOT EXISTS (
+    SELECT 1 FROM auction_results
+    WHERE auction_results.product_id = products.id
+  )
+ORDER BY auction_end
+`
+
+func (q *Queries) ListUnsettledEndedProducts(ctx context.Context, now time.Time) ([]Product, error) {
+	rows, err := q.db.Query(ctx, listUnsettledEndedProducts, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.ProductName,
+			&i.Description,
+			&i.BasePrice,
+			&i.AuctionEnd,
+			&i.IsSold,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+			&i.BidIncrements,
+			&i.ReservePrice,
+			&i.BuyNowPrice,
+			&i.AuctionType,
+			&i.PriceDropAmount,
+			&i.PriceDropIntervalSeconds,
+			&i.AuctionStart,
+			&i.FloorPrice,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateProductAuctionEnd = `-- name: UpdateProductAuctionEnd :exec
+UPDATE products
+SET auction_end = $2, updated_at = now()
+WHERE id = $1
+`
+
+type UpdateProductAuctionEndParams struct {
+	ID         uuid.UUID `json:"id"`
+	AuctionEnd time.Time `json:"auction_end"`
+}
+
+func (q *Queries) UpdateProductAuctionEnd(ctx context.Context, arg UpdateProductAuctionEndParams) error {
+	_, err := q.db.Exec(ctx, updateProductAuctionEnd, arg.ID, arg.AuctionEnd)
+	return err
+}
+
+const listProducts = `-- name: ListProducts :many
+SELECT products.id, products.seller_id, products.product_name, products.description, products.base_price, products.auction_end, products.is_sold, products.created_at, products.updated_at, products.currency, products.bid_increments, products.reserve_price, products.buy_now_price, products.auction_type, products.price_drop_amount, products.price_drop_interval_seconds, products.auction_start, products.floor_price, listing.high_bid, listing.bid_count, listing.current_price, listing.sort_key
+FROM products
+CROSS JOIN LATERAL (
+  SELECT MAX(bid_amount) AS high_bid, COUNT(*) AS bid_count
+  FROM bids
+  WHERE bids.product_id = products.id
+) bid_stats
+CROSS JOIN LATERAL (
+  SELECT
+    COALESCE(bid_stats.high_bid, 0)::bigint AS high_bid,
+    bid_stats.bid_count::bigint AS bid_count,
+    (CASE
+      WHEN products.auction_type = 'english' THEN GREATEST(products.base_price, COALESCE(bid_stats.high_bid, 0))
+      WHEN products.auction_type = 'dutch' AND bid_stats.high_bid IS NOT NULL THEN bid_stats.high_bid
+      WHEN products.auction_type = 'dutch' THEN GREATEST(products.base_price - LEAST(
+        floor(GREATEST(extract(epoch FROM $1::timestamptz - products.auction_start), 0) / products.price_drop_interval_seconds)::bigint,
+        (products.base_price - products.floor_price + products.price_drop_amount - 1) / products.price_drop_amount
+      ) * products.price_drop_amount, products.floor_price)
+      ELSE products.base_price
+    END)::bigint AS current_price
+) price
+CROSS JOIN LATERAL (
+  SELECT
+    price.high_bid,
+    price.bid_count,
+    price.current_price,
+    (CASE $2::text
+      WHEN 'newest' THEN -(extract(epoch FROM products.created_at) * 1000000)::bigint
+      WHEN 'price_asc' THEN price.current_price
+      WHEN 'price_desc' THEN -price.current_price
+      ELSE (extract(epoch FROM products.auction_end) * 1000000)::bigint
+    END)::bigint AS sort_key
+) listing
+WHERE (
+    $3::text IS NULL
+    OR ($3 = 'scheduled' AND NOT products.is_sold AND products.auction_start > $1)
+    OR ($3 = 'active' AND NOT products.is_sold AND products.auction_start <= $1 AND products.auction_end > $1)
+    OR ($3 = 'ended' AND NOT products.is_sold AND products.auction_end <= $1)
+    OR ($3 = 'sold' AND products.is_sold)
+  )
+  AND ($4::uuid IS NULL OR products.seller_id = $4)
+  AND ($5::bigint IS NULL OR listing.current_price >= $5)
+  AND ($6::bigint IS NULL OR listing.current_price <= $6)
+  AND ($7::timestamptz IS NULL OR products.auction_end < $7)
+  AND (
+    $8::text IS NULL
+    OR to_tsvector('english', products.product_name || ' ' || products.description) @@ websearch_to_tsquery('english', $8)
+  )
+  AND (
+    $9::bigint IS NULL
+    OR (listing.sort_key, products.id) > ($9, $10::uuid)
+  )
+ORDER BY listing.sort_key, products.id
+LIMIT $11
+`
+
+type ListProductsParams struct {
+	Now          time.Time          `json:"now"`
+	SortBy       string             `json:"sort_by"`
+	Status       pgtype.Text        `json:"status"`
+	SellerID     pgtype.UUID        `json:"seller_id"`
+	MinPrice     pgtype.Int8        `json:"min_price"`
+	MaxPrice     pgtype.Int8        `json:"max_price"`
+	EndingBefore pgtype.Timestamptz `json:"ending_before"`
+	Search       pgtype.Text        `json:"search"`
+	AfterSortKey pgtype.Int8        `json:"after_sort_key"`
+	AfterID      pgtype.UUID        `json:"after_id"`
+	PageSize     int32              `json:"page_size"`
+}
+
+type ListProductsRow struct {
+	Product      Product `json:"product"`
+	HighBid      int64   `json:"high_bid"`
+	BidCount     int64   `json:"bid_count"`
+	CurrentPrice int64   `json:"current_price"`
+	SortKey      int64   `json:"sort_key"`
+}
+
+func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]ListProductsRow, error) {
+	rows, err := q.db.Query(ctx, listProducts,
+		arg.Now,
+		arg.SortBy,
+		arg.Status,
+		arg.SellerID,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.EndingBefore,
+		arg.Search,
+		arg.AfterSortKey,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductsRow
+	for rows.Next() {
+		var i ListProductsRow
+		if err := rows.Scan(
+			&i.Product.ID,
+			&i.Product.SellerID,
+			&i.Product.ProductName,
+			&i.Product.Description,
+			&i.Product.BasePrice,
+			&i.Product.AuctionEnd,
+			&i.Product.IsSold,
+			&i.Product.CreatedAt,
+			&i.Product.UpdatedAt,
+			&i.Product.Currency,
+			&i.Product.BidIncrements,
+			&i.Product.ReservePrice,
+			&i.Product.BuyNowPrice,
+			&i.Product.AuctionType,
+			&i.Product.PriceDropAmount,
+			&i.Product.PriceDropIntervalSeconds,
+			&i.Product.AuctionStart,
+			&i.Product.FloorPrice,
+			&i.HighBid,
+			&i.BidCount,
+			&i.CurrentPrice,
+			&i.SortKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProductListingById = `-- name: GetProductListingById :one
+SELECT products.id, products.seller_id, products.product_name, products.description, products.base_price, products.auction_end, products.is_sold, products.created_at, products.updated_at, products.currency, products.bid_increments, products.reserve_price, products.buy_now_price, products.auction_type, products.price_drop_amount, products.price_drop_interval_seconds, products.auction_start, products.floor_price, COALESCE(bid_stats.high_bid, 0)::bigint AS high_bid, bid_stats.bid_count::bigint AS bid_count
+FROM products
+CROSS JOIN LATERAL (
+  SELECT MAX(bid_amount) AS high_bid, COUNT(*) AS bid_count
+  FROM bids
+  WHERE bids.product_id = products.id
+) bid_stats
+WHERE products.id = $1
+`
+
+type GetProductListingByIdRow struct {
+	Product  Product `json:"product"`
+	HighBid  int64   `json:"high_bid"`
+	BidCount int64   `json:"bid_count"`
+}
+
+func (q *Queries) GetProductListingById(ctx context.Context, id uuid.UUID) (GetProductListingByIdRow, error) {
+	row := q.db.QueryRow(ctx, getProductListingById, id)
+	var i GetProductListingByIdRow
+	err := row.Scan(
+		&i.Product.ID,
+		&i.Product.SellerID,
+		&i.Product.ProductName,
+		&i.Product.Description,
+		&i.Product.BasePrice,
+		&i.Product.AuctionEnd,
+		&i.Product.IsSold,
+		&i.Product.CreatedAt,
+		&i.Product.UpdatedAt,
+		&i.Product.Currency,
+		&i.Product.BidIncrements,
+		&i.Product.ReservePrice,
+		&i.Product.BuyNowPrice,
+		&i.Product.AuctionType,
+		&i.Product.PriceDropAmount,
+		&i.Product.PriceDropIntervalSeconds,
+		&i.Product.AuctionStart,
+		&i.Product.FloorPrice,
+		&i.HighBid,
+		&i.BidCount,
 	)
 	return i, err
 }
@@ -0,0 +1,8 @@
+-- name: CreateAuctionResult :one
+INSERT INTO auction_results (product_id, winner_id, winning_bid_id, final_price, currency, status)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetAuctionResultByProductId :one
+SELECT * FROM auction_results
+WHERE product_id = $1;
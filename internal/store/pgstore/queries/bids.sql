@@ -1,4 +1,13 @@
 -- name: CreateBid :one
+-- The bidder of a product's first bid gets the next bidder number of the
+-- product, which later bids and sealed bid revisions keep.
+WITH bidder AS (
+  INSERT INTO auction_bidders (product_id, bidder_id, bidder_number)
+  SELECT $1, $2, COALESCE(MAX(bidder_number), 0) + 1
+  FROM auction_bidders
+  WHERE product_id = $1
+  ON CONFLICT (product_id, bidder_id) DO NOTHING
+)
 INSERT INTO bids (product_id, bidder_id, bid_amount)
 VALUES ($1, $2, $3)
 RETURNING *;
@@ -11,5 +20,30 @@ ORDER BY bid_amount DESC;
 -- name: GetHighestBidByProductId :one
 SELECT * FROM bids
 WHERE product_id = $1
-ORDER BY bid_amount DESC
+ORDER BY bid_amount DESC, created_at ASC
 LIMIT 1;
+
+-- name: ListTopBidsByProductId :many
+SELECT * FROM bids
+WHERE product_id = $1
+ORDER BY bid_amount DESC, created_at ASC
+LIMIT $2;
+
+-- name: DeleteBidsByProductIdAndBidderId :exec
+DELETE FROM bids
+WHERE product_id = $1 AND bidder_id = $2;
+
+-- name: ListBidHistoryByProductId :many
+SELECT bids.id, bids.bidder_id, bids.bid_amount, bids.created_at, bids.seq, users.user_name, auction_bidders.bidder_number
+FROM bids
+JOIN users ON users.id = bids.bidder_id
+JOIN auction_bidders ON auction_bidders.product_id = bids.product_id AND auction_bidders.bidder_id = bids.bidder_id
+WHERE bids.product_id = sqlc.arg(product_id)
+  AND (sqlc.narg(before_seq)::bigint IS NULL OR bids.seq < sqlc.narg(before_seq))
+ORDER BY bids.seq DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetBidStatsByProductId :one
+SELECT COALESCE(MAX(bid_amount), 0)::bigint AS high_bid, COUNT(*) AS bid_count
+FROM bids
+WHERE product_id = $1;
@@ -1,8 +1,108 @@
 -- name: CreateProduct :one
-INSERT INTO products (seller_id, product_name, description, base_price, auction_end)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO products (seller_id, product_name, description, base_price, currency, auction_end, bid_increments, reserve_price, buy_now_price, auction_type, price_drop_amount, price_drop_interval_seconds, auction_start, floor_price)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 RETURNING id;
 
 -- name: GetProductById :one
 SELECT * FROM products
 WHERE id = $1;
+
+-- name: ListActiveProducts :many
+SELECT * FROM products
+WHERE is_sold = false AND auction_start <= sqlc.arg(now) AND auction_end > sqlc.arg(now)
+ORDER BY auction_end;
+
+-- name: ListScheduledProducts :many
+SELECT * FROM products
+WHERE is_sold = false AND auction_start > sqlc.arg(now)
+ORDER BY auction_start;
+
+-- name: GetProductByIdForUpdate :one
+SELECT * FROM products
+WHERE id = $1
+FOR UPDATE;
+
+-- name: MarkProductAsSold :exec
+UPDATE products
+SET is_sold = true, updated_at = now()
+WHERE id = $1;
+
+-- name: ListUnsettledEndedProducts :many
+SELECT * FROM products
+WHERE auction_end <= sqlc.arg(now)
+  AND NOT EXISTS (
+    SELECT 1 FROM auction_results
+    WHERE auction_results.product_id = products.id
+  )
+ORDER BY auction_end;
+
+-- name: UpdateProductAuctionEnd :exec
+UPDATE products
+SET auction_end = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: ListProducts :many
+SELECT sqlc.embed(products), listing.high_bid, listing.bid_count, listing.current_price, listing.sort_key
+FROM products
+CROSS JOIN LATERAL (
+  SELECT MAX(bid_amount) AS high_bid, COUNT(*) AS bid_count
+  FROM bids
+  WHERE bids.product_id = products.id
+) bid_stats
+CROSS JOIN LATERAL (
+  SELECT
+    COALESCE(bid_stats.high_bid, 0)::bigint AS high_bid,
+    bid_stats.bid_count::bigint AS bid_count,
+    (CASE
+      WHEN products.auction_type = 'english' THEN GREATEST(products.base_price, COALESCE(bid_stats.high_bid, 0))
+      WHEN products.auction_type = 'dutch' AND bid_stats.high_bid IS NOT NULL THEN bid_stats.high_bid
+      WHEN products.auction_type = 'dutch' THEN GREATEST(products.base_price - LEAST(
+        floor(GREATEST(extract(epoch FROM sqlc.arg(now)::timestamptz - products.auction_start), 0) / products.price_drop_interval_seconds)::bigint,
+        (products.base_price - products.floor_price + products.price_drop_amount - 1) / products.price_drop_amount
+      ) * products.price_drop_amount, products.floor_price)
+      ELSE products.base_price
+    END)::bigint AS current_price
+) price
+CROSS JOIN LATERAL (
+  SELECT
+    price.high_bid,
+    price.bid_count,
+    price.current_price,
+    (CASE sqlc.arg(sort_by)::text
+      WHEN 'newest' THEN -(extract(epoch FROM products.created_at) * 1000000)::bigint
+      WHEN 'price_asc' THEN price.current_price
+      WHEN 'price_desc' THEN -price.current_price
+      ELSE (extract(epoch FROM products.auction_end) * 1000000)::bigint
+    END)::bigint AS sort_key
+) listing
+WHERE (
+    sqlc.narg(status)::text IS NULL
+    OR (sqlc.narg(status) = 'scheduled' AND NOT products.is_sold AND products.auction_start > sqlc.arg(now))
+    OR (sqlc.narg(status) = 'active' AND NOT products.is_sold AND products.auction_start <= sqlc.arg(now) AND products.auction_end > sqlc.arg(now))
+    OR (sqlc.narg(status) = 'ended' AND NOT products.is_sold AND products.auction_end <= sqlc.arg(now))
+    OR (sqlc.narg(status) = 'sold' AND products.is_sold)
+  )
+  AND (sqlc.narg(seller_id)::uuid IS NULL OR products.seller_id = sqlc.narg(seller_id))
+  AND (sqlc.narg(min_price)::bigint IS NULL OR listing.current_price >= sqlc.narg(min_price))
+  AND (sqlc.narg(max_price)::bigint IS NULL OR listing.current_price <= sqlc.narg(max_price))
+  AND (sqlc.narg(ending_before)::timestamptz IS NULL OR products.auction_end < sqlc.narg(ending_before))
+  AND (
+    sqlc.narg(search)::text IS NULL
+    OR to_tsvector('english', products.product_name || ' ' || products.description) @@ websearch_to_tsquery('english', sqlc.narg(search))
+  )
+  AND (
+    sqlc.narg(after_sort_key)::bigint IS NULL
+    OR (listing.sort_key, products.id) > (sqlc.narg(after_sort_key), sqlc.narg(after_id)::uuid)
+  )
+ORDER BY listing.sort_key, products.id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetProductListingById :one
+SELECT sqlc.embed(products), COALESCE(bid_stats.high_bid, 0)::bigint AS high_bid, bid_stats.bid_count::bigint AS bid_count
+FROM products
+CROSS JOIN LATERAL (
+  SELECT MAX(bid_amount) AS high_bid, COUNT(*) AS bid_count
+  FROM bids
+  WHERE bids.product_id = products.id
+) bid_stats
+WHERE products.id = $1;
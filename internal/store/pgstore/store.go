@@ -0,0 +1,59 @@
+package pgstore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store runs queries, on their own or inside a transaction. Services depend
+// on it rather than on a pool so they can run against the in-memory store in
+// tests.
+type Store interface {
+	Querier
+	Begin(ctx context.Context) (Tx, error)
+}
+
+// Tx runs queries inside a transaction. Rollback is a no-op once the
+// transaction has been committed, so it can always be deferred.
+type Tx interface {
+	Querier
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// PoolStore is the Store backed by Postgres.
+type PoolStore struct {
+	*Queries
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *PoolStore {
+	return &PoolStore{
+		Queries: New(pool),
+		pool:    pool,
+	}
+}
+
+func (s *PoolStore) Begin(ctx context.Context) (Tx, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &poolTx{Queries: s.WithTx(tx), tx: tx}, nil
+}
+
+type poolTx struct {
+	*Queries
+	tx pgx.Tx
+}
+
+func (t *poolTx) Commit(ctx context.Context) error {
+	return t.tx.Commit(ctx)
+}
+
+func (t *poolTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback(ctx)
+}
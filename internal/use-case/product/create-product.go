@@ -2,23 +2,56 @@ package product
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/FelipeBelloDultra/go-bid/internal/clock"
+	"github.com/FelipeBelloDultra/go-bid/internal/money"
+	"github.com/FelipeBelloDultra/go-bid/internal/store/pgstore"
 	"github.com/FelipeBelloDultra/go-bid/internal/validator"
 	"github.com/google/uuid"
 )
 
 type CreateProductReq struct {
-	SellerID    uuid.UUID `json:"seller_id"`
-	ProductName string    `json:"product_name"`
-	Description string    `json:"description"`
-	BasePrice   float64   `json:"base_price"`
-	AuctionEnd  time.Time `json:"auction_end"`
+	SellerID    uuid.UUID   `json:"seller_id"`
+	ProductName string      `json:"product_name"`
+	Description string      `json:"description"`
+	BasePrice   money.Money `json:"base_price"`
+	// ReservePrice is optional and never disclosed to bidders: the product is
+	// only sold when the winning bid reaches it.
+	ReservePrice *money.Money `json:"reserve_price"`
+	// BuyNowPrice is optional and lets a bidder end the auction by paying it
+	// while there are no bids (or, with a reserve, no bid meeting it).
+	BuyNowPrice *money.Money `json:"buy_now_price"`
+	// AuctionStart is optional; the auction opens as soon as the product is
+	// created when it is omitted.
+	AuctionStart time.Time `json:"auction_start"`
+	AuctionEnd   time.Time `json:"auction_end"`
+	// BidIncrements is optional; the money.DefaultIncrementSchedule of the
+	// currency is used when it is omitted.
+	BidIncrements money.IncrementSchedule `json:"bid_increments"`
+	// AuctionType defaults to an open ascending (english) auction.
+	AuctionType pgstore.AuctionType `json:"auction_type"`
+	// PriceDrop, PriceDropInterval (in seconds) and FloorPrice are required
+	// by dutch auctions, whose asking price starts at base_price and drops by
+	// PriceDrop every interval down to the public FloorPrice.
+	PriceDrop         *money.Money `json:"price_drop"`
+	PriceDropInterval int32        `json:"price_drop_interval"`
+	FloorPrice        *money.Money `json:"floor_price"`
 }
 
-const minAuctionDuration = 2 * time.Hour
+// CreateProductRules are the configurable limits a CreateProductReq is
+// validated against.
+type CreateProductRules struct {
+	// Clock tells what time it is, so auction dates can be checked against
+	// the same time as the auctions run on.
+	Clock clock.Clock
+	// MinAuctionDuration is the shortest auction a seller may create,
+	// measured from auction_start.
+	MinAuctionDuration time.Duration
+}
 
-func (req CreateProductReq) Valid(ctx context.Context) validator.Evaluator {
+func (req CreateProductReq) Valid(ctx context.Context, rules CreateProductRules) validator.Evaluator {
 	var eval validator.Evaluator
 
 	eval.CheckField(
@@ -37,19 +70,119 @@ func (req CreateProductReq) Valid(ctx context.Context) validator.Evaluator {
 		"this field must have length between 10 and 255 characters",
 	)
 	eval.CheckField(
-		req.BasePrice > 0,
+		req.BasePrice.IsPositive(),
 		"base_price",
 		"this field must be greater than 0",
 	)
 	eval.CheckField(
-		!req.AuctionEnd.IsZero() && req.AuctionEnd.After(time.Now()),
+		money.IsValidCurrency(req.BasePrice.Currency),
+		"base_price",
+		"this field must have a supported ISO 4217 currency",
+	)
+	isDutch := req.AuctionType == pgstore.AuctionTypeDutch
+	if req.ReservePrice != nil {
+		eval.CheckField(
+			req.ReservePrice.SameCurrency(req.BasePrice),
+			"reserve_price",
+			"this field must use the same currency as base_price",
+		)
+		eval.CheckField(
+			req.ReservePrice.Amount >= req.BasePrice.Amount,
+			"reserve_price",
+			"this field must be greater than or equal to base_price",
+		)
+		// The asking price of a dutch auction would stop dropping at the
+		// reserve and disclose it.
+		eval.CheckField(
+			!isDutch,
+			"reserve_price",
+			"this field is not supported by dutch auctions",
+		)
+	}
+	if req.BuyNowPrice != nil {
+		eval.CheckField(
+			req.BuyNowPrice.SameCurrency(req.BasePrice),
+			"buy_now_price",
+			"this field must use the same currency as base_price",
+		)
+		eval.CheckField(
+			req.BuyNowPrice.Amount > req.BasePrice.Amount,
+			"buy_now_price",
+			"this field must be greater than base_price",
+		)
+		eval.CheckField(
+			req.ReservePrice == nil || req.BuyNowPrice.Amount >= req.ReservePrice.Amount,
+			"buy_now_price",
+			"this field must be greater than or equal to reserve_price",
+		)
+	}
+	eval.CheckField(
+		req.AuctionType == "" || req.AuctionType.Valid(),
+		"auction_type",
+		"this field must be english, sealed_first_price, sealed_second_price or dutch",
+	)
+	if isDutch {
+		eval.CheckField(
+			req.PriceDrop != nil && req.PriceDrop.IsPositive() && req.PriceDrop.SameCurrency(req.BasePrice),
+			"price_drop",
+			"this field must be greater than 0 and use the same currency as base_price",
+		)
+		eval.CheckField(
+			req.PriceDropInterval > 0,
+			"price_drop_interval",
+			"this field must be greater than 0",
+		)
+		eval.CheckField(
+			req.FloorPrice != nil && req.FloorPrice.IsPositive() && req.FloorPrice.SameCurrency(req.BasePrice),
+			"floor_price",
+			"this field must be greater than 0 and use the same currency as base_price",
+		)
+		eval.CheckField(
+			req.FloorPrice == nil || req.FloorPrice.Amount < req.BasePrice.Amount,
+			"floor_price",
+			"this field must be less than base_price",
+		)
+	} else {
+		eval.CheckField(
+			req.PriceDrop == nil && req.PriceDropInterval == 0,
+			"price_drop",
+			"this field is only available for dutch auctions",
+		)
+		eval.CheckField(
+			req.FloorPrice == nil,
+			"floor_price",
+			"this field is only available for dutch auctions",
+		)
+	}
+	eval.CheckField(
+		req.BuyNowPrice == nil || req.AuctionType == "" || req.AuctionType == pgstore.AuctionTypeEnglish,
+		"buy_now_price",
+		"this field is only available for english auctions",
+	)
+	eval.CheckField(
+		req.BidIncrements == nil || req.BidIncrements.Valid(),
+		"bid_increments",
+		"this field must start at 0 with ascending bands and positive increments",
+	)
+	now := rules.Clock.Now()
+	auctionStart := now
+	if !req.AuctionStart.IsZero() {
+		eval.CheckField(
+			req.AuctionStart.After(auctionStart),
+			"auction_start",
+			"this field must be a future date",
+		)
+		auctionStart = req.AuctionStart
+	}
+	eval.CheckField(
+		!req.AuctionEnd.IsZero() && req.AuctionEnd.After(now),
 		"auction_end",
 		"this field must be a future date",
 	)
 	eval.CheckField(
-		time.Until(req.AuctionEnd) >= minAuctionDuration,
+		req.AuctionEnd.Sub(auctionStart) >= rules.MinAuctionDuration,
 		"auction_end",
-		"this field must be at least 2 hours duration",
+		fmt.Sprintf("this field must be at least %s after auction_start", rules.MinAuctionDuration),
 	)
 
 	return eval
@@ -0,0 +1,50 @@
+package product
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/validator"
+)
+
+// ListBidsReq holds the query string of a product's bid history.
+type ListBidsReq struct {
+	ShowUserNames bool
+	Cursor        string
+	Limit         int32
+
+	problems validator.Evaluator
+}
+
+func NewListBidsReq(query url.Values) ListBidsReq {
+	req := ListBidsReq{
+		Cursor: query.Get("cursor"),
+	}
+
+	if raw := query.Get("show_user_names"); raw != "" {
+		showUserNames, err := strconv.ParseBool(raw)
+		if err != nil {
+			req.problems.AddFieldError("show_user_names", "this field must be a boolean")
+		}
+		req.ShowUserNames = showUserNames
+	}
+	req.Limit = parseLimit(&req.problems, query.Get("limit"))
+
+	return req
+}
+
+func (req ListBidsReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+	for key, message := range req.problems {
+		eval.AddFieldError(key, message)
+	}
+
+	eval.CheckField(
+		req.Limit >= 1 && req.Limit <= maxListLimit,
+		"limit",
+		"this field must be between 1 and 100",
+	)
+
+	return eval
+}
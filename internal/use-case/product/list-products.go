@@ -0,0 +1,132 @@
+package product
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/FelipeBelloDultra/go-bid/internal/services"
+	"github.com/FelipeBelloDultra/go-bid/internal/validator"
+	"github.com/google/uuid"
+)
+
+// ListProductsReq holds the query string of a product listing. Parameters
+// that cannot be parsed are reported by Valid with the other problems.
+type ListProductsReq struct {
+	Status       string
+	SellerID     *uuid.UUID
+	MinPrice     *int64
+	MaxPrice     *int64
+	EndingBefore *time.Time
+	Search       string
+	SortBy       string
+	Cursor       string
+	Limit        int32
+
+	problems validator.Evaluator
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+func NewListProductsReq(query url.Values) ListProductsReq {
+	req := ListProductsReq{
+		Status: query.Get("status"),
+		Search: query.Get("q"),
+		SortBy: query.Get("sort"),
+		Cursor: query.Get("cursor"),
+		Limit:  defaultListLimit,
+	}
+
+	if raw := query.Get("seller_id"); raw != "" {
+		sellerId, err := uuid.Parse(raw)
+		if err != nil {
+			req.problems.AddFieldError("seller_id", "this field must be a valid uuid")
+		} else {
+			req.SellerID = &sellerId
+		}
+	}
+	req.MinPrice = parseInt(&req.problems, "min_price", query.Get("min_price"))
+	req.MaxPrice = parseInt(&req.problems, "max_price", query.Get("max_price"))
+	if raw := query.Get("ending_before"); raw != "" {
+		endingBefore, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			req.problems.AddFieldError("ending_before", "this field must be an RFC 3339 date")
+		} else {
+			req.EndingBefore = &endingBefore
+		}
+	}
+	req.Limit = parseLimit(&req.problems, query.Get("limit"))
+
+	return req
+}
+
+// parseInt parses an optional integer parameter, recording a problem for key
+// when it is malformed.
+func parseInt(problems *validator.Evaluator, key, raw string) *int64 {
+	if raw == "" {
+		return nil
+	}
+
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		problems.AddFieldError(key, "this field must be an integer")
+		return nil
+	}
+
+	return &value
+}
+
+// parseLimit parses the page size, clamping it to one past the valid range so
+// that Valid reports it without overflowing int32.
+func parseLimit(problems *validator.Evaluator, raw string) int32 {
+	limit := parseInt(problems, "limit", raw)
+	if limit == nil {
+		return defaultListLimit
+	}
+
+	return int32(max(min(*limit, maxListLimit+1), 0))
+}
+
+func (req ListProductsReq) Valid(ctx context.Context) validator.Evaluator {
+	var eval validator.Evaluator
+	for key, message := range req.problems {
+		eval.AddFieldError(key, message)
+	}
+
+	switch req.Status {
+	case "", services.ProductStatusScheduled, services.ProductStatusActive, services.ProductStatusEnded, services.ProductStatusSold:
+	default:
+		eval.AddFieldError("status", "this field must be scheduled, active, ended or sold")
+	}
+	switch req.SortBy {
+	case "", services.ProductSortEndingSoon, services.ProductSortNewest, services.ProductSortPriceAsc, services.ProductSortPriceDesc:
+	default:
+		eval.AddFieldError("sort", "this field must be ending_soon, newest, price_asc or price_desc")
+	}
+	eval.CheckField(
+		req.MinPrice == nil || *req.MinPrice >= 0,
+		"min_price",
+		"this field must be greater than or equal to 0",
+	)
+	eval.CheckField(
+		req.MaxPrice == nil || req.MinPrice == nil || *req.MaxPrice >= *req.MinPrice,
+		"max_price",
+		"this field must be greater than or equal to min_price",
+	)
+	eval.CheckField(
+		validator.MaxChars(req.Search, 255),
+		"q",
+		"this field must have at most 255 characters",
+	)
+	eval.CheckField(
+		req.Limit >= 1 && req.Limit <= maxListLimit,
+		"limit",
+		"this field must be between 1 and 100",
+	)
+
+	return eval
+}
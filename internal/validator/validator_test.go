@@ -0,0 +1,32 @@
+package validator
+
+import "testing"
+
+func TestEmailRegex(t *testing.T) {
+	for _, email := range []string{
+		"user@gobid.test",
+		"first.last+tag@example.com",
+		"o'hara@mail.example.co.uk",
+		"x@localhost",
+	} {
+		if !Matches(email, EmailRegex) {
+			t.Errorf("expected %q to be a valid email", email)
+		}
+	}
+
+	for _, email := range []string{
+		"",
+		"user",
+		"user@",
+		"@gobid.test",
+		"user@@gobid.test",
+		"user@-gobid.test",
+		"user@gobid..test",
+		"user @gobid.test",
+		"/user@gobid.test/",
+	} {
+		if Matches(email, EmailRegex) {
+			t.Errorf("expected %q to be an invalid email", email)
+		}
+	}
+}